@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Action is something the player can do, bound to keys, mouse buttons and gamepad buttons or axes.
+type Action string
+
+const (
+	ActionMoveUp    Action = "MoveUp"
+	ActionMoveDown  Action = "MoveDown"
+	ActionMoveLeft  Action = "MoveLeft"
+	ActionMoveRight Action = "MoveRight"
+	ActionAttack    Action = "Attack"
+	ActionShoot     Action = "Shoot"
+	ActionPause     Action = "Pause"
+	ActionConfirm   Action = "Confirm"
+	ActionCancel    Action = "Cancel"
+)
+
+// Actions lists every action in the order they're shown and saved.
+var Actions = []Action{
+	ActionMoveUp, ActionMoveDown, ActionMoveLeft, ActionMoveRight,
+	ActionAttack, ActionShoot, ActionPause, ActionConfirm, ActionCancel,
+}
+
+const (
+	// an axis bound to an action presses it once it's pushed this far
+	AXIS_PRESS_THRESHOLD = 0.5
+	// MoveVector ignores sticks pushed less than this, so worn sticks don't drift
+	STICK_DEADZONE = 0.2
+)
+
+// DefaultBindings are the controls used for actions the config file doesn't set.
+var DefaultBindings = map[Action][]string{
+	ActionMoveUp:    {"key:W", "key:ArrowUp", "pad:DPadUp", "axis:LeftY-"},
+	ActionMoveDown:  {"key:S", "key:ArrowDown", "pad:DPadDown", "axis:LeftY+"},
+	ActionMoveLeft:  {"key:A", "key:ArrowLeft", "pad:DPadLeft", "axis:LeftX-"},
+	ActionMoveRight: {"key:D", "key:ArrowRight", "pad:DPadRight", "axis:LeftX+"},
+	ActionAttack:    {"key:F", "pad:X"},
+	ActionShoot:     {"key:Space", "mouse:Left", "pad:RB"},
+	ActionPause:     {"key:Escape", "pad:Start"},
+	ActionConfirm:   {"key:Enter", "pad:A"},
+	ActionCancel:    {"key:Escape", "pad:B"},
+}
+
+type BindingKind int
+
+const (
+	BINDING_KEY BindingKind = iota
+	BINDING_MOUSE
+	BINDING_PAD_BUTTON
+	BINDING_PAD_AXIS
+)
+
+// Binding is one input that triggers an action. In config files it's written as
+// "key:<ebiten key name>", "mouse:Left|Right|Middle", "pad:<button>" or "axis:<axis>+|-".
+type Binding struct {
+	Kind   BindingKind
+	Key    ebiten.Key
+	Mouse  ebiten.MouseButton
+	Button ebiten.StandardGamepadButton
+	Axis   ebiten.StandardGamepadAxis
+	// AxisDir is 1 or -1, the way the axis has to be pushed
+	AxisDir float64
+}
+
+var mouseButtonNames = map[string]ebiten.MouseButton{
+	"Left":   ebiten.MouseButtonLeft,
+	"Right":  ebiten.MouseButtonRight,
+	"Middle": ebiten.MouseButtonMiddle,
+}
+
+// gamepad buttons are named after an Xbox controller
+var padButtonNames = map[string]ebiten.StandardGamepadButton{
+	"A":         ebiten.StandardGamepadButtonRightBottom,
+	"B":         ebiten.StandardGamepadButtonRightRight,
+	"X":         ebiten.StandardGamepadButtonRightLeft,
+	"Y":         ebiten.StandardGamepadButtonRightTop,
+	"LB":        ebiten.StandardGamepadButtonFrontTopLeft,
+	"RB":        ebiten.StandardGamepadButtonFrontTopRight,
+	"LT":        ebiten.StandardGamepadButtonFrontBottomLeft,
+	"RT":        ebiten.StandardGamepadButtonFrontBottomRight,
+	"Back":      ebiten.StandardGamepadButtonCenterLeft,
+	"Start":     ebiten.StandardGamepadButtonCenterRight,
+	"Home":      ebiten.StandardGamepadButtonCenterCenter,
+	"LS":        ebiten.StandardGamepadButtonLeftStick,
+	"RS":        ebiten.StandardGamepadButtonRightStick,
+	"DPadUp":    ebiten.StandardGamepadButtonLeftTop,
+	"DPadDown":  ebiten.StandardGamepadButtonLeftBottom,
+	"DPadLeft":  ebiten.StandardGamepadButtonLeftLeft,
+	"DPadRight": ebiten.StandardGamepadButtonLeftRight,
+}
+
+var padAxisNames = map[string]ebiten.StandardGamepadAxis{
+	"LeftX":  ebiten.StandardGamepadAxisLeftStickHorizontal,
+	"LeftY":  ebiten.StandardGamepadAxisLeftStickVertical,
+	"RightX": ebiten.StandardGamepadAxisRightStickHorizontal,
+	"RightY": ebiten.StandardGamepadAxisRightStickVertical,
+}
+
+// ParseBinding reads a binding written as in config files, see Binding.
+func ParseBinding(s string) (Binding, error) {
+	kind, name, ok := strings.Cut(s, ":")
+	if !ok {
+		return Binding{}, fmt.Errorf("binding %q: want <kind>:<name>", s)
+	}
+	switch kind {
+	case "key":
+		var k ebiten.Key
+		if err := k.UnmarshalText([]byte(name)); err != nil {
+			return Binding{}, fmt.Errorf("binding %q: %w", s, err)
+		}
+		return Binding{Kind: BINDING_KEY, Key: k}, nil
+	case "mouse":
+		if b, ok := mouseButtonNames[name]; ok {
+			return Binding{Kind: BINDING_MOUSE, Mouse: b}, nil
+		}
+	case "pad":
+		if b, ok := padButtonNames[name]; ok {
+			return Binding{Kind: BINDING_PAD_BUTTON, Button: b}, nil
+		}
+	case "axis":
+		dir := 1.0
+		switch {
+		case strings.HasSuffix(name, "+"):
+		case strings.HasSuffix(name, "-"):
+			dir = -1
+		default:
+			return Binding{}, fmt.Errorf("binding %q: axis needs a + or - direction", s)
+		}
+		if a, ok := padAxisNames[name[:len(name)-1]]; ok {
+			return Binding{Kind: BINDING_PAD_AXIS, Axis: a, AxisDir: dir}, nil
+		}
+	default:
+		return Binding{}, fmt.Errorf("binding %q: unknown kind %q", s, kind)
+	}
+	return Binding{}, fmt.Errorf("binding %q: unknown %s %q", s, kind, name)
+}
+
+func (b Binding) String() string {
+	switch b.Kind {
+	case BINDING_KEY:
+		return "key:" + b.Key.String()
+	case BINDING_MOUSE:
+		return "mouse:" + nameOf(mouseButtonNames, b.Mouse)
+	case BINDING_PAD_BUTTON:
+		return "pad:" + nameOf(padButtonNames, b.Button)
+	case BINDING_PAD_AXIS:
+		dir := "+"
+		if b.AxisDir < 0 {
+			dir = "-"
+		}
+		return "axis:" + nameOf(padAxisNames, b.Axis) + dir
+	}
+	return "?"
+}
+
+func nameOf[T comparable](names map[string]T, v T) string {
+	for name, x := range names {
+		if x == v {
+			return name
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Binding) UnmarshalText(text []byte) error {
+	parsed, err := ParseBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// Value returns how far the binding is pressed, from 0 to 1.
+func (b Binding) Value(in Input) float64 {
+	pressed := false
+	switch b.Kind {
+	case BINDING_KEY:
+		pressed = in.IsKeyPressed(b.Key)
+	case BINDING_MOUSE:
+		pressed = in.IsMouseButtonPressed(b.Mouse)
+	case BINDING_PAD_BUTTON:
+		pressed = in.IsGamepadButtonPressed(b.Button)
+	case BINDING_PAD_AXIS:
+		return max(0, min(1, in.GamepadAxisValue(b.Axis)*b.AxisDir))
+	}
+	if pressed {
+		return 1
+	}
+	return 0
+}
+
+// ActionMap resolves actions from their bindings once per frame, see Update.
+type ActionMap struct {
+	bindings map[Action][]Binding
+	values   map[Action]float64
+	pressed  map[Action]bool
+	prev     map[Action]bool
+}
+
+// NewActionMap returns an action map using DefaultBindings.
+func NewActionMap() *ActionMap {
+	m := &ActionMap{
+		bindings: make(map[Action][]Binding),
+		values:   make(map[Action]float64),
+		pressed:  make(map[Action]bool),
+		prev:     make(map[Action]bool),
+	}
+	m.ResetAll()
+	return m
+}
+
+// Reset restores the default bindings of an action.
+func (m *ActionMap) Reset(a Action) {
+	m.bindings[a] = m.bindings[a][:0]
+	for _, s := range DefaultBindings[a] {
+		b, err := ParseBinding(s)
+		if err != nil {
+			panic(err)
+		}
+		m.bindings[a] = append(m.bindings[a], b)
+	}
+}
+
+func (m *ActionMap) ResetAll() {
+	for _, a := range Actions {
+		m.Reset(a)
+	}
+}
+
+// Bindings returns the bindings of an action. The slice must not be modified.
+func (m *ActionMap) Bindings(a Action) []Binding {
+	return m.bindings[a]
+}
+
+// SetBindings replaces every binding of an action.
+func (m *ActionMap) SetBindings(a Action, bindings ...Binding) {
+	m.bindings[a] = append([]Binding(nil), bindings...)
+}
+
+// Bind adds a binding to an action, if it isn't bound to it yet.
+func (m *ActionMap) Bind(a Action, b Binding) {
+	for _, x := range m.bindings[a] {
+		if x == b {
+			return
+		}
+	}
+	m.bindings[a] = append(m.bindings[a], b)
+}
+
+// Unbind removes a binding from an action.
+func (m *ActionMap) Unbind(a Action, b Binding) {
+	kept := m.bindings[a][:0]
+	for _, x := range m.bindings[a] {
+		if x != b {
+			kept = append(kept, x)
+		}
+	}
+	m.bindings[a] = kept
+}
+
+// Update reads every action's bindings from in. It's called once at the start of every frame.
+func (m *ActionMap) Update(in Input) {
+	for a, bindings := range m.bindings {
+		v := 0.0
+		for _, b := range bindings {
+			v = max(v, b.Value(in))
+		}
+		m.prev[a] = m.pressed[a]
+		m.values[a] = v
+		m.pressed[a] = v >= AXIS_PRESS_THRESHOLD
+	}
+}
+
+// Feed replaces the values this frame's Update read from the input; actions missing from values
+// are released. JustPressed still compares with the previous frame. Replays drive the game with it.
+func (m *ActionMap) Feed(values map[Action]float64) {
+	for _, a := range Actions {
+		v := values[a]
+		m.values[a] = v
+		m.pressed[a] = v >= AXIS_PRESS_THRESHOLD
+	}
+}
+
+// Pressed reports whether the action is held.
+func (m *ActionMap) Pressed(a Action) bool {
+	return m.pressed[a]
+}
+
+// JustPressed reports whether the action started being held this frame.
+func (m *ActionMap) JustPressed(a Action) bool {
+	return m.pressed[a] && !m.prev[a]
+}
+
+// Value returns how far the action is pressed, from 0 to 1; analog for gamepad axes.
+func (m *ActionMap) Value(a Action) float64 {
+	return m.values[a]
+}
+
+// MoveVector returns the direction of the Move actions, with a length from 0 to 1: analog with a
+// stick, past a radial deadzone, and always 1 with keys or buttons, diagonals included.
+func (m *ActionMap) MoveVector() (float64, float64) {
+	x := m.values[ActionMoveRight] - m.values[ActionMoveLeft]
+	y := m.values[ActionMoveDown] - m.values[ActionMoveUp]
+	l := math.Hypot(x, y)
+	if l < STICK_DEADZONE {
+		return 0, 0
+	}
+	// rescale so the speed starts from 0 at the edge of the deadzone
+	scaled := min(1, (l-STICK_DEADZONE)/(1-STICK_DEADZONE))
+	return x / l * scaled, y / l * scaled
+}
+
+// Load merges the bindings of a controls file into the map: actions it lists replace their
+// bindings, the others keep theirs. A missing file is not an error.
+func (m *ActionMap) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file map[Action][]Binding
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for a, bindings := range file {
+		if _, ok := DefaultBindings[a]; !ok {
+			return fmt.Errorf("%s: unknown action %q", path, a)
+		}
+		m.SetBindings(a, bindings...)
+	}
+	return nil
+}
+
+// Save writes every action's bindings to a controls file, creating its directory.
+func (m *ActionMap) Save(path string) error {
+	file := make(map[Action][]Binding, len(m.bindings))
+	for a, bindings := range m.bindings {
+		file[a] = bindings
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ControlsConfigPath returns where the player's controls are saved, in the user's config directory.
+func ControlsConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "controls.json"
+	}
+	return filepath.Join(dir, "BulletQuest2D", "controls.json")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestBindingRoundTrip(t *testing.T) {
+	for _, bindings := range DefaultBindings {
+		for _, s := range bindings {
+			b, err := ParseBinding(s)
+			if err != nil {
+				t.Errorf("%s: %v", s, err)
+				continue
+			}
+			if b.String() != s {
+				t.Errorf("%s is written back as %s", s, b)
+			}
+		}
+	}
+	for _, s := range []string{"W", "key:NoSuchKey", "pad:Z", "axis:LeftX", "joystick:A"} {
+		if _, err := ParseBinding(s); err == nil {
+			t.Errorf("%s should not parse", s)
+		}
+	}
+}
+
+func TestActionMapResolvesBindings(t *testing.T) {
+	m := NewActionMap()
+	in := NewScriptedInput()
+
+	in.Press(ebiten.KeyArrowUp)
+	m.Update(in)
+	if !m.Pressed(ActionMoveUp) || !m.JustPressed(ActionMoveUp) {
+		t.Error("ArrowUp should press MoveUp")
+	}
+	m.Update(in)
+	if !m.Pressed(ActionMoveUp) || m.JustPressed(ActionMoveUp) {
+		t.Error("MoveUp is held, not just pressed, on the second frame")
+	}
+
+	in.ReleaseAll()
+	in.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, -0.3)
+	m.Update(in)
+	if m.Pressed(ActionMoveLeft) || m.Value(ActionMoveLeft) != 0.3 {
+		t.Errorf("a light push should only give a value: pressed %v value %v", m.Pressed(ActionMoveLeft), m.Value(ActionMoveLeft))
+	}
+	in.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, -0.9)
+	m.Update(in)
+	if !m.Pressed(ActionMoveLeft) || m.Pressed(ActionMoveRight) {
+		t.Error("pushing the stick left should press MoveLeft only")
+	}
+}
+
+func TestActionMapRebindAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "controls.json")
+	m := NewActionMap()
+	k, _ := ParseBinding("key:K")
+	m.SetBindings(ActionAttack, k)
+	m.Unbind(ActionShoot, Binding{Kind: BINDING_MOUSE, Mouse: ebiten.MouseButtonLeft})
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewActionMap()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Bindings(ActionAttack); len(got) != 1 || got[0] != k {
+		t.Errorf("Attack bindings after loading: %v", got)
+	}
+	if got := loaded.Bindings(ActionShoot); len(got) != len(DefaultBindings[ActionShoot])-1 {
+		t.Errorf("Shoot bindings after loading: %v", got)
+	}
+
+	// a partial file only changes the actions it lists
+	if err := os.WriteFile(path, []byte(`{"Pause": ["key:P"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	partial := NewActionMap()
+	if err := partial.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(partial.Bindings(ActionPause)) != 1 || len(partial.Bindings(ActionMoveUp)) != len(DefaultBindings[ActionMoveUp]) {
+		t.Error("a partial controls file should keep the other defaults")
+	}
+	if err := partial.Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("a missing controls file should not be an error: %v", err)
+	}
+}
+
+func TestMoveVectorDeadzone(t *testing.T) {
+	m := NewActionMap()
+	in := NewScriptedInput()
+	axisX := ebiten.StandardGamepadAxisLeftStickHorizontal
+	axisY := ebiten.StandardGamepadAxisLeftStickVertical
+
+	in.SetAxis(axisX, 0.1)
+	in.SetAxis(axisY, -0.1)
+	m.Update(in)
+	if x, y := m.MoveVector(); x != 0 || y != 0 {
+		t.Errorf("stick inside the deadzone moved %v, %v", x, y)
+	}
+
+	in.SetAxis(axisX, 0.6)
+	in.SetAxis(axisY, 0)
+	m.Update(in)
+	want := (0.6 - STICK_DEADZONE) / (1 - STICK_DEADZONE)
+	if x, y := m.MoveVector(); math.Abs(x-want) > 1e-9 || y != 0 {
+		t.Errorf("stick at 0.6 moved %v, %v; want %v, 0", x, y, want)
+	}
+
+	// keys give full speed diagonals, not faster ones
+	in.ReleaseAll()
+	in.Press(ebiten.KeyD, ebiten.KeyS)
+	m.Update(in)
+	if x, y := m.MoveVector(); math.Abs(math.Hypot(x, y)-1) > 1e-9 || x != y {
+		t.Errorf("diagonal keys moved %v, %v", x, y)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+// clips every character is expected to define, see assets/characters.json
+const (
+	ANIM_ATTACK = "attack"
+	ANIM_HURT   = "hurt"
+	ANIM_DIE    = "die"
+)
+
+// Animator plays the animation clips of a character definition. Looping clips run until
+// another clip is played; one-shot clips can't be interrupted by Play, and when they end
+// they run their completion callback and switch to their Next clip, or hold their last frame.
+type Animator struct {
+	Def *CharacterDef
+	// Clip is the name of the clip playing and Frame its current frame
+	Clip  string
+	Frame int
+
+	// seconds spent on the current frame
+	time    float64
+	oneShot bool
+	done    bool
+	onDone  func()
+}
+
+func NewAnimator(def *CharacterDef) Animator {
+	return Animator{Def: def, Clip: ANIM_IDLE}
+}
+
+func (a *Animator) clip(name string) AnimationDef {
+	if a.Def == nil {
+		return AnimationDef{Start: 0, Frames: 1}
+	}
+	return a.Def.Action(name)
+}
+
+// Play switches to a clip unless it's already the current one or a one-shot clip hasn't finished.
+func (a *Animator) Play(name string) {
+	if a.Clip == name || (a.oneShot && !a.done) {
+		return
+	}
+	a.start(name, a.clip(name).OneShot, nil)
+}
+
+// PlayOnce restarts a clip as a one-shot, even over another one-shot, and calls onDone when it ends.
+// The previous clip's callback is dropped.
+func (a *Animator) PlayOnce(name string, onDone func()) {
+	a.start(name, true, onDone)
+}
+
+func (a *Animator) start(name string, oneShot bool, onDone func()) {
+	a.Clip = name
+	a.Frame = 0
+	a.time = 0
+	a.oneShot = oneShot
+	a.done = false
+	a.onDone = onDone
+}
+
+// Playing reports whether name is the current clip and hasn't finished.
+func (a *Animator) Playing(name string) bool {
+	return a.Clip == name && !a.done
+}
+
+// Update advances the current clip by delta seconds.
+func (a *Animator) Update(delta float64) {
+	if a.done {
+		return
+	}
+	clip := a.clip(a.Clip)
+	a.time += delta
+	for a.time >= clip.FrameDuration(a.Frame) {
+		a.time -= clip.FrameDuration(a.Frame)
+		if a.Frame+1 < clip.Frames {
+			a.Frame++
+			continue
+		}
+		if !a.oneShot {
+			a.Frame = 0
+			continue
+		}
+
+		// a one-shot clip ended: hold its last frame unless it moves on
+		a.done = true
+		a.time = 0
+		onDone := a.onDone
+		a.onDone = nil
+		if clip.Next != "" {
+			a.start(clip.Next, a.clip(clip.Next).OneShot, nil)
+		}
+		if onDone != nil {
+			onDone()
+		}
+		return
+	}
+}
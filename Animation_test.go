@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func testAnimDef(t *testing.T) *CharacterDef {
+	defs, err := parseCharacterDefs([]byte(`{"hero": {"actions": {
+		"idle":   {"start": 0, "frames": 1},
+		"walk":   {"start": 0, "frames": 4, "frameTime": 0.1},
+		"attack": {"start": 4, "frames": 2, "durations": [0.1, 0.3], "oneShot": true, "next": "idle"},
+		"die":    {"start": 6, "frames": 1, "frameTime": 0.5, "oneShot": true}
+	}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return defs["hero"]
+}
+
+func TestAnimatorLoops(t *testing.T) {
+	a := NewAnimator(testAnimDef(t))
+	a.Play(ANIM_WALK)
+	for i := 0; i < 5; i++ {
+		a.Update(0.1001)
+	}
+	if a.Clip != ANIM_WALK || a.Frame != 1 {
+		t.Errorf("after 5 frames of a 4 frame loop: %s frame %d, want walk frame 1", a.Clip, a.Frame)
+	}
+	// playing the same clip again doesn't restart it
+	a.Play(ANIM_WALK)
+	if a.Frame != 1 {
+		t.Error("Play restarted the running clip")
+	}
+}
+
+func TestAnimatorOneShot(t *testing.T) {
+	a := NewAnimator(testAnimDef(t))
+	done := 0
+	a.PlayOnce(ANIM_ATTACK, func() { done++ })
+
+	a.Update(0.15)
+	if a.Frame != 1 {
+		t.Fatalf("frame %d, want 1 after the first frame's duration", a.Frame)
+	}
+	a.Play(ANIM_WALK)
+	if !a.Playing(ANIM_ATTACK) {
+		t.Fatal("Play interrupted a one-shot clip")
+	}
+	a.Update(0.3)
+	if done != 1 || a.Clip != ANIM_IDLE {
+		t.Fatalf("after the attack: callback called %d times, clip %s; want 1 and idle", done, a.Clip)
+	}
+	a.Play(ANIM_WALK)
+	if !a.Playing(ANIM_WALK) {
+		t.Error("Play should work again once the one-shot ended")
+	}
+
+	a.PlayOnce(ANIM_DIE, nil)
+	a.Update(1)
+	a.Play(ANIM_DIE)
+	if a.Clip != ANIM_DIE || a.Playing(ANIM_DIE) {
+		t.Errorf("a one-shot without next should hold its last frame, got %s playing %v", a.Clip, a.Playing(ANIM_DIE))
+	}
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"image"
 	"sync"
+
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 var MENU_START = image.Rect(0, 0, 320, 160)
 var PLAYING_MENU = image.Rect(0, 0, 320, 160)
 
+// atlas every button image is cut from
+const BUTTON_ATLAS_PATH = "assets/bluebuttons.png"
+
+// ButtonImages is a kind of button of the atlas: MenuStart is the play button of the menu,
+// PlayingMenu the menu button, used to leave the menu and to pause the game.
 type ButtonImages int
 
 const (
@@ -25,14 +35,23 @@ var (
 	MenuPushedRect = image.Rect(208, 0, 208+16, 0+16) // (208,0)-(224,16)
 )
 
+// buttonRects are the atlas regions of every kind, normal then pushed.
+var buttonRects = map[ButtonImages][2]image.Rectangle{
+	MenuStart:   {PlayingNormalRect, PlayingPushedRect},
+	PlayingMenu: {MenuNormalRect, MenuPushedRect},
+}
+
 type ButtonState struct {
-	PlayingNormal image.Image
-	PlayingPushed image.Image
-	MenuNormal    image.Image
-	MenuPushed    image.Image
-	Width         int
-	Height        int
-	ButtonHitbox  *image.Rectangle
+	Normal *ebiten.Image
+	Pushed *ebiten.Image
+	// Width and Height are the size of the images in the atlas
+	Width  int
+	Height int
+	// ButtonHitbox is the opaque part of the normal image, relative to its top-left corner
+	ButtonHitbox image.Rectangle
+
+	// scaled caches the images GetScaledBitmap made, by scale, normal then pushed
+	scaled map[int][2]*ebiten.Image
 }
 
 var (
@@ -40,109 +59,137 @@ var (
 	mu           sync.RWMutex
 )
 
-func (b ButtonImages) Init(resID int, width, height int) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	state := &ButtonState{
-		Width:  width,
-		Height: height,
+// LoadButtonImages loads the atlas and cuts the images of every kind of button from it.
+func LoadButtonImages(path string) error {
+	atlas, src, err := ebitenutil.NewImageFromFile(path)
+	if err != nil {
+		return err
+	}
+	for b := range buttonRects {
+		if err := b.Init(atlas, src); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
 	}
+	return nil
+}
 
-	// Load button atlas and create sub-images
-	// Note: You'll need to implement actual image loading based on your resource system
-	// This is a placeholder structure
+// Init cuts the button's images from the atlas; src is the decoded atlas, which the hitbox is measured on.
+func (b ButtonImages) Init(atlas *ebiten.Image, src image.Image) error {
+	rects, ok := buttonRects[b]
+	if !ok {
+		return fmt.Errorf("unknown button %d", b)
+	}
+	for _, r := range rects {
+		if !r.In(atlas.Bounds()) {
+			return fmt.Errorf("button %d region %v is outside the atlas", b, r)
+		}
+	}
 
-	buttonStates[b] = state
+	mu.Lock()
+	defer mu.Unlock()
+	buttonStates[b] = &ButtonState{
+		Normal:       atlas.SubImage(rects[0]).(*ebiten.Image),
+		Pushed:       atlas.SubImage(rects[1]).(*ebiten.Image),
+		Width:        rects[0].Dx(),
+		Height:       rects[0].Dy(),
+		ButtonHitbox: opaqueBounds(src, rects[0]).Sub(rects[0].Min),
+		scaled:       make(map[int][2]*ebiten.Image),
+	}
+	return nil
 }
 
-func (b ButtonImages) GetScaledBitmap(img image.Image, buttonScale int) image.Image {
-	// Returns scaled bitmap - implementation depends on your image library
-	return img
+// opaqueBounds returns the smallest rectangle holding every pixel of r that isn't fully transparent,
+// or r when it's all transparent.
+func opaqueBounds(img image.Image, r image.Rectangle) image.Rectangle {
+	var bounds image.Rectangle
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if bounds.Empty() {
+		return r
+	}
+	return bounds
 }
 
-func (b ButtonImages) GetMenuButtonImage(isPushed bool) image.Image {
+func (b ButtonImages) state() *ButtonState {
 	mu.RLock()
 	defer mu.RUnlock()
-
-	state := buttonStates[b]
-	if isPushed {
-		return state.MenuPushed
-	}
-	return state.MenuNormal
+	return buttonStates[b]
 }
 
-func (b ButtonImages) GetPlayingButtonImage(isPushed bool) image.Image {
-	mu.RLock()
-	defer mu.RUnlock()
+// Loaded reports whether the button's images were loaded.
+func (b ButtonImages) Loaded() bool {
+	return b.state() != nil
+}
 
-	state := buttonStates[b]
+// Image returns the button's image as it is in the atlas, nil when it isn't loaded.
+func (b ButtonImages) Image(isPushed bool) *ebiten.Image {
+	state := b.state()
+	if state == nil {
+		return nil
+	}
 	if isPushed {
-		return state.PlayingPushed
+		return state.Pushed
 	}
-	return state.PlayingNormal
+	return state.Normal
 }
 
-func (b ButtonImages) getWidthPlayingNormal() int {
-	mu.RLock()
-	defer mu.RUnlock()
+// GetScaledBitmap returns the button's image scaled up buttonScale times, nil when it isn't loaded.
+// The scaled images are made once and kept.
+func (b ButtonImages) GetScaledBitmap(isPushed bool, buttonScale int) *ebiten.Image {
+	mu.Lock()
+	defer mu.Unlock()
 	state := buttonStates[b]
-	return state.PlayingNormal.Bounds().Dx()
+	if state == nil || buttonScale < 1 {
+		return nil
+	}
+	images, ok := state.scaled[buttonScale]
+	if !ok {
+		for i, src := range []*ebiten.Image{state.Normal, state.Pushed} {
+			img := ebiten.NewImage(state.Width*buttonScale, state.Height*buttonScale)
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Scale(float64(buttonScale), float64(buttonScale))
+			img.DrawImage(src, op)
+			images[i] = img
+		}
+		state.scaled[buttonScale] = images
+	}
+	if isPushed {
+		return images[1]
+	}
+	return images[0]
 }
 
-func (b ButtonImages) getHeightPlayingNormal() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.PlayingNormal.Bounds().Dy()
-}
-func (b ButtonImages) getWidthPlayingPushed() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.PlayingPushed.Bounds().Dx()
-}
-func (b ButtonImages) getHeightPlayingPushed() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.PlayingPushed.Bounds().Dy()
-}
-func (b ButtonImages) getWidthMenuNormal() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.MenuNormal.Bounds().Dx()
-}
-func (b ButtonImages) getHeightMenuNormal() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.MenuNormal.Bounds().Dy()
-}
-func (b ButtonImages) getWidthMenuPushed() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.MenuPushed.Bounds().Dx()
-}
-func (b ButtonImages) getHeightMenuPushed() int {
-	mu.RLock()
-	defer mu.RUnlock()
-	state := buttonStates[b]
-	return state.MenuPushed.Bounds().Dy()
+// Size returns the size of the button's images scaled buttonScale times.
+func (b ButtonImages) Size(buttonScale int) image.Point {
+	state := b.state()
+	if state == nil {
+		return image.Point{}
+	}
+	return image.Pt(state.Width*buttonScale, state.Height*buttonScale)
 }
 
-func (b ButtonImages) Menu_getBtnImg(isBtnPushed bool) image.Image {
-	if isBtnPushed {
-		return b.GetPlayingButtonImage(true)
+// Hitbox returns the clickable part of the button scaled buttonScale times, relative to its top-left corner.
+func (b ButtonImages) Hitbox(buttonScale int) image.Rectangle {
+	state := b.state()
+	if state == nil {
+		return image.Rectangle{}
 	}
-	return b.GetPlayingButtonImage(false)
+	r := state.ButtonHitbox
+	return image.Rectangle{Min: r.Min.Mul(buttonScale), Max: r.Max.Mul(buttonScale)}
 }
 
-func (b ButtonImages) Playing_getBtnImg(isBtnPushed bool) image.Image {
-	if isBtnPushed {
-		return b.GetMenuButtonImage(true)
+// NewButton returns a ui button drawn with the button's images scaled buttonScale times,
+// or a text button with the label when they aren't loaded.
+func (b ButtonImages) NewButton(label string, buttonScale int, onClick func()) *ui.Button {
+	if !b.Loaded() {
+		return ui.NewButton(label, onClick)
 	}
-	return b.GetMenuButtonImage(false)
+	btn := ui.NewImageButton(b.GetScaledBitmap(false, buttonScale), b.GetScaledBitmap(true, buttonScale), 1, onClick)
+	btn.Hitbox = b.Hitbox(buttonScale)
+	return btn
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// loadTestButtonImages loads the atlas for one test and forgets it afterwards, so the other
+// tests keep their text buttons.
+func loadTestButtonImages(t *testing.T) {
+	t.Helper()
+	if err := LoadButtonImages(BUTTON_ATLAS_PATH); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		clear(buttonStates)
+		mu.Unlock()
+	})
+}
+
+func TestButtonImagesFromAtlas(t *testing.T) {
+	loadTestButtonImages(t)
+	for b, rects := range buttonRects {
+		if !b.Loaded() {
+			t.Fatalf("button %d not loaded", b)
+		}
+		if got := b.Image(false).Bounds(); got != rects[0] {
+			t.Errorf("button %d normal image is %v, want %v", b, got, rects[0])
+		}
+		if got := b.Image(true).Bounds(); got != rects[1] {
+			t.Errorf("button %d pushed image is %v, want %v", b, got, rects[1])
+		}
+
+		size := b.Size(2)
+		if want := rects[0].Size().Mul(2); size != want {
+			t.Errorf("button %d size at scale 2 is %v, want %v", b, size, want)
+		}
+		scaled := b.GetScaledBitmap(true, 2)
+		if got := scaled.Bounds().Size(); got != size {
+			t.Errorf("button %d scaled image is %v, want %v", b, got, size)
+		}
+		if b.GetScaledBitmap(true, 2) != scaled {
+			t.Errorf("button %d scaled image isn't kept", b)
+		}
+
+		hitbox := b.Hitbox(2)
+		if hitbox.Empty() || !hitbox.In(image.Rectangle{Max: size}) {
+			t.Errorf("button %d hitbox %v isn't within %v", b, hitbox, size)
+		}
+
+		btn := b.NewButton("label", 2, nil)
+		if got := btn.MinSize(); got != size {
+			t.Errorf("button %d ui button is %v, want %v", b, got, size)
+		}
+		if btn.Hitbox != hitbox {
+			t.Errorf("button %d ui button hitbox is %v, want %v", b, btn.Hitbox, hitbox)
+		}
+	}
+}
+
+func TestButtonImagesNotLoaded(t *testing.T) {
+	if MenuStart.Loaded() || MenuStart.Image(false) != nil || MenuStart.GetScaledBitmap(false, 1) != nil {
+		t.Fatal("no images before the atlas is loaded")
+	}
+	if got := MenuStart.Size(2); got != (image.Point{}) {
+		t.Errorf("size without images is %v", got)
+	}
+	btn := MenuStart.NewButton("Play", 2, nil)
+	if btn.Label != "Play" || btn.Images[0] != nil {
+		t.Errorf("without images the button should show its label, got %q", btn.Label)
+	}
+	if err := LoadButtonImages("assets/missing.png"); err == nil {
+		t.Error("loading a missing atlas should fail")
+	}
+}
+
+func TestHeadlessMenuButtonPauses(t *testing.T) {
+	sm, p, in := headlessScene(t)
+	r := p.MenuButton.Bounds()
+	if r.Empty() || !r.In(image.Rect(0, 0, 320, 128)) {
+		t.Fatalf("menu button at %v isn't on screen", r)
+	}
+	in.MoveCursor(r.Min.X+2, r.Min.Y+2)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	if p.Player.Gun == nil || p.projectiles.ActiveCount() != 0 {
+		t.Fatalf("holding the mouse on the menu button shot %d projectiles", p.projectiles.ActiveCount())
+	}
+	in.ReleaseMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	if _, ok := sm.Current().(*PauseScene); !ok {
+		t.Fatalf("clicking the menu button should pause the game, scene is %T", sm.Current())
+	}
+	if !p.Clock.Paused {
+		t.Error("the clock runs under the pause menu")
+	}
+}
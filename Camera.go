@@ -1,9 +1,15 @@
 package main
 
+import "image"
+
 type Camera struct {
-	X, Y       float64
-	ScreenW    int
-	ScreenH    int
+	X, Y    float64
+	ScreenW int
+	ScreenH int
+	// WorldX/WorldY is the top-left corner of the world, which is negative for
+	// infinite maps extending left of or above 0,0.
+	WorldX     int
+	WorldY     int
 	WorldW     int
 	WorldH     int
 	FollowedCh *Character
@@ -18,6 +24,12 @@ func NewCamera(screenW, screenH, worldW, worldH int) *Camera {
 	}
 }
 
+// SetWorldBounds sets the area (in pixels) the camera is clamped to.
+func (c *Camera) SetWorldBounds(r image.Rectangle) {
+	c.WorldX, c.WorldY = r.Min.X, r.Min.Y
+	c.WorldW, c.WorldH = r.Dx(), r.Dy()
+}
+
 func (c *Camera) FollowCharacter(ch *Character) {
 	c.FollowedCh = ch
 }
@@ -33,17 +45,22 @@ func (c *Camera) Update() {
 	c.Y = c.FollowedCh.Position.Y - float64(c.ScreenH)/2
 
 	// Clamp the camera position to the world bounds
-	if c.X < 0 {
-		c.X = 0
+	if c.X < float64(c.WorldX) {
+		c.X = float64(c.WorldX)
 	}
-	if c.Y < 0 {
-		c.Y = 0
+	if c.Y < float64(c.WorldY) {
+		c.Y = float64(c.WorldY)
 	}
-	if c.X > float64(c.WorldW-c.ScreenW) {
-		c.X = float64(c.WorldW - c.ScreenW)
+	if c.X > float64(c.WorldX+c.WorldW-c.ScreenW) {
+		c.X = float64(c.WorldX + c.WorldW - c.ScreenW)
 	}
-	if c.Y > float64(c.WorldH-c.ScreenH) {
-		c.Y = float64(c.WorldH - c.ScreenH)
+	if c.Y > float64(c.WorldY+c.WorldH-c.ScreenH) {
+		c.Y = float64(c.WorldY + c.WorldH - c.ScreenH)
 	}
 
 }
+
+// WorldToScreen converts a world position to the screen.
+func (c *Camera) WorldToScreen(x, y float64) (float64, float64) {
+	return x - c.X, y - c.Y
+}
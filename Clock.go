@@ -0,0 +1,94 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+const (
+	// simulation steps per second of game time
+	DEFAULT_SIM_TPS = 60
+	// at most this many steps run in one frame, so a long hitch slows the game down instead
+	// of freezing it while it catches up
+	MAX_SIM_STEPS_PER_FRAME = 8
+)
+
+// SimClock turns the time between frames into a whole number of fixed simulation steps.
+// Leftover time is carried over to the next frame, so the game runs at the same speed
+// whatever ebiten's TPS is, and every step sees the same delta.
+type SimClock struct {
+	// TimeScale multiplies the game time: below 1 is slow motion, 0 stops the simulation
+	TimeScale float64
+	Paused    bool
+	// FrameTime returns the real time in seconds since the previous frame; by default one ebiten tick
+	FrameTime func() float64
+
+	tps         int
+	step        float64
+	accumulator float64
+	// Ticks counts the steps run so far and Elapsed the game time they covered
+	Ticks   uint64
+	Elapsed float64
+	// LastFrame is the frame time read by the latest Tick and Frames the number of Ticks
+	LastFrame float64
+	Frames    uint64
+}
+
+func NewSimClock(tps int) *SimClock {
+	c := &SimClock{TimeScale: 1, FrameTime: ebitenFrameTime}
+	c.SetTPS(tps)
+	return c
+}
+
+func ebitenFrameTime() float64 {
+	return 1 / float64(ebiten.TPS())
+}
+
+// SetTPS changes the number of simulation steps per second. Values below 1 are ignored.
+func (c *SimClock) SetTPS(tps int) {
+	if tps < 1 {
+		return
+	}
+	c.tps = tps
+	c.step = 1 / float64(tps)
+}
+
+func (c *SimClock) TPS() int {
+	return c.tps
+}
+
+// Step returns the fixed delta of every simulation step, in seconds.
+func (c *SimClock) Step() float64 {
+	return c.step
+}
+
+// Alpha returns how far the simulation is between the previous and the next step, from 0 to 1,
+// for drawing code that wants to interpolate.
+func (c *SimClock) Alpha() float64 {
+	return c.accumulator / c.step
+}
+
+// Advance adds frame seconds of real time and returns how many steps should run.
+func (c *SimClock) Advance(frame float64) int {
+	if c.Paused || c.TimeScale <= 0 || frame <= 0 {
+		return 0
+	}
+	c.accumulator += frame * c.TimeScale
+	steps := int(c.accumulator / c.step)
+	if steps > MAX_SIM_STEPS_PER_FRAME {
+		steps = MAX_SIM_STEPS_PER_FRAME
+		c.accumulator = 0
+	} else {
+		c.accumulator -= float64(steps) * c.step
+	}
+	return steps
+}
+
+// Tick advances the clock by one frame and calls update once per simulation step.
+func (c *SimClock) Tick(update func(delta float64)) {
+	c.LastFrame = c.FrameTime()
+	c.Frames++
+	steps := c.Advance(c.LastFrame)
+	for i := 0; i < steps; i++ {
+		update(c.step)
+		c.Ticks++
+		c.Elapsed += c.step
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// runClock ticks the clock for seconds of real time at the given display TPS and returns the steps run.
+func runClock(c *SimClock, displayTPS int, seconds float64) int {
+	c.FrameTime = func() float64 { return 1 / float64(displayTPS) }
+	steps := 0
+	for i := 0; i < int(seconds*float64(displayTPS)); i++ {
+		c.Tick(func(float64) { steps++ })
+	}
+	return steps
+}
+
+func TestSimClockIgnoresDisplayTPS(t *testing.T) {
+	for _, tps := range []int{30, 60, 144} {
+		c := NewSimClock(60)
+		if got := runClock(c, tps, 2); math.Abs(float64(got-120)) > 1 {
+			t.Errorf("display at %d TPS ran %d steps in 2s, want 120", tps, got)
+		}
+		if math.Abs(c.Elapsed-float64(c.Ticks)*c.Step()) > 1e-9 {
+			t.Errorf("elapsed %v doesn't match %d ticks", c.Elapsed, c.Ticks)
+		}
+	}
+}
+
+func TestSimClockTimeScale(t *testing.T) {
+	c := NewSimClock(60)
+	c.TimeScale = 0.5
+	if got := runClock(c, 60, 2); math.Abs(float64(got-60)) > 1 {
+		t.Errorf("half speed ran %d steps in 2s, want 60", got)
+	}
+	c.Paused = true
+	if got := runClock(c, 60, 1); got != 0 {
+		t.Errorf("paused clock ran %d steps", got)
+	}
+}
+
+func TestSimClockClampsHitches(t *testing.T) {
+	c := NewSimClock(60)
+	if got := c.Advance(5); got != MAX_SIM_STEPS_PER_FRAME {
+		t.Errorf("a 5s hitch ran %d steps, want %d", got, MAX_SIM_STEPS_PER_FRAME)
+	}
+	if c.Alpha() != 0 {
+		t.Error("the time beyond the clamp should be dropped")
+	}
+}
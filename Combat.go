@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// attackHitbox returns the area in front of the character a weapon covers, based on FaceDir.
+func attackHitbox(c *Character, w *Weapon) RectF {
+	hb := c.HitboxAt(c.Position.X, c.Position.Y)
+	cx, cy := hb.X+hb.W/2, hb.Y+hb.H/2
+	switch c.GetFaceDir() {
+	case FACE_DIR_RIGHT:
+		return RectF{X: hb.X + hb.W, Y: cy - w.Width/2, W: w.Reach, H: w.Width}
+	case FACE_DIR_LEFT:
+		return RectF{X: hb.X - w.Reach, Y: cy - w.Width/2, W: w.Reach, H: w.Width}
+	case FACE_DIR_UP:
+		return RectF{X: cx - w.Width/2, Y: hb.Y - w.Reach, W: w.Width, H: w.Reach}
+	default:
+		return RectF{X: cx - w.Width/2, Y: hb.Y + hb.H, W: w.Width, H: w.Reach}
+	}
+}
+
+// faceAngle returns the angle (radians, clockwise from the right) the character faces.
+func faceAngle(faceDir int) float64 {
+	switch faceDir {
+	case FACE_DIR_RIGHT:
+		return 0
+	case FACE_DIR_LEFT:
+		return math.Pi
+	case FACE_DIR_UP:
+		return -math.Pi / 2
+	default:
+		return math.Pi / 2
+	}
+}
+
+// updatePlayerAttack starts a swing when the attack key is held and the weapon is ready,
+// and hits the enemies inside the weapon's hitbox while the swing is active.
+// Every enemy is hit at most once per swing.
+func (p *PlayScene) updatePlayerAttack(delta float64) {
+	pl := p.Player
+	if pl == nil || pl.Weapon == nil {
+		return
+	}
+	w := pl.Weapon
+
+	if pl.attackCooldown > 0 {
+		pl.attackCooldown -= delta
+	}
+	if pl.IsSwinging() {
+		pl.swingTime += delta
+		if pl.swingTime >= w.SwingTime {
+			pl.swingTime = -1
+		}
+	}
+	if pl.Attacking && !pl.IsSwinging() && pl.attackCooldown <= 0 {
+		pl.swingTime = 0
+		pl.attackCooldown = w.Cooldown
+		pl.Anim.PlayOnce(ANIM_ATTACK, nil)
+		pl.swingHits = pl.swingHits[:0]
+	}
+	if !pl.IsSwinging() || pl.swingTime > w.ActiveTime {
+		return
+	}
+
+	hitbox := attackHitbox(pl.Character, w)
+	px, py := pl.Center()
+	for _, e := range p.Enemies {
+		if !e.IsAlive() || pl.alreadyHit(e) {
+			continue
+		}
+		if hitbox.Intersects(e.HitboxAt(e.Position.X, e.Position.Y)) {
+			pl.swingHits = append(pl.swingHits, e)
+			e.Hit(w.Damage, px, py, w.Knockback)
+		}
+	}
+}
+
+// updatePlayerShoot fires the player's gun in the facing direction while shooting.
+func (p *PlayScene) updatePlayerShoot(delta float64) {
+	pl := p.Player
+	if pl == nil || pl.Gun == nil {
+		return
+	}
+	if pl.shotCooldown > 0 {
+		pl.shotCooldown -= delta
+	}
+	if !pl.Shooting || pl.shotCooldown > 0 {
+		return
+	}
+	dx, dy := faceVector(pl.GetFaceDir())
+	if p.FireProjectile(pl.Character, PROJECTILE_TEAM_PLAYER, pl.Gun, dx, dy) != nil {
+		pl.shotCooldown = pl.Gun.Cooldown
+	}
+}
+
+// drawSwing draws the equipped weapon sweeping through an arc in front of the player.
+func (p *PlayScene) drawSwing(screen *ebiten.Image) {
+	pl := p.Player
+	if pl == nil || pl.Weapon == nil || pl.Weapon.Image == nil || !pl.IsSwinging() {
+		return
+	}
+	w := pl.Weapon
+	img := w.Image
+	bounds := img.Bounds()
+
+	// the icons point to the top-right, so the blade points along angle 0 after a -45° turn
+	const arc = math.Pi / 3
+	progress := pl.swingTime / w.SwingTime
+	angle := faceAngle(pl.GetFaceDir()) - arc + 2*arc*progress + math.Pi/4
+
+	op := &ebiten.DrawImageOptions{}
+	// pivot around the handle in the bottom-left corner
+	op.GeoM.Translate(0, -float64(bounds.Dy()))
+	op.GeoM.Scale(WEAPON_DRAW_SIZE/float64(bounds.Dx()), WEAPON_DRAW_SIZE/float64(bounds.Dy()))
+	op.GeoM.Rotate(angle)
+	cx, cy := pl.Center()
+	op.GeoM.Translate(cx, cy)
+	if p.Camera != nil {
+		op.GeoM.Translate(-p.Camera.X, -p.Camera.Y)
+	}
+	screen.DrawImage(img, op)
+}
+
+// swingSortY puts the swing in front of the player unless they face up.
+func (p *PlayScene) swingSortY() float64 {
+	if p.Player.GetFaceDir() == FACE_DIR_UP {
+		return p.Player.FootY() - 0.5
+	}
+	return p.Player.FootY() + 0.5
+}
+
+// Hit applies a hit from an attacker at fromX, fromY: damage, a red flash, a knockback
+// through the map's collision and a short stun. It returns whether it landed.
+func (e *Enemy) Hit(damage int, fromX, fromY, knockback float64) bool {
+	if !e.IsAlive() || !e.TakeHit(damage, fromX, fromY, knockback) {
+		return false
+	}
+	if e.IsAlive() {
+		e.stunTime = ENEMY_HIT_STUN
+		e.setState(ENEMY_STATE_CHASE)
+		e.Anim.PlayOnce(ANIM_HURT, nil)
+	}
+	return true
+}
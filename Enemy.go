@@ -0,0 +1,254 @@
+package main
+
+import (
+	"math"
+)
+
+type EnemyState int
+
+const (
+	ENEMY_STATE_IDLE EnemyState = iota
+	ENEMY_STATE_WANDER
+	ENEMY_STATE_CHASE
+	ENEMY_STATE_ATTACK
+	ENEMY_STATE_RETURN
+	ENEMY_STATE_DIE
+)
+
+// Enemy tuning. Speeds are in pixels per second, distances in pixels and times in seconds.
+const (
+	ENEMY_DEFAULT_HP  = 3
+	ENEMY_CHASE_SPEED = 55
+	// enemies wander at this fraction of their speed
+	ENEMY_WANDER_SPEED_SCALE = 0.55
+	ENEMY_SIGHT_RANGE        = 80
+	ENEMY_LOSE_RANGE         = 120
+	// a chasing enemy farther than this from its home gives up and walks back
+	ENEMY_LEASH_RANGE = 160
+	// a returning enemy this close to its home is back
+	ENEMY_HOME_RANGE      = 2
+	ENEMY_ATTACK_RANGE    = 14
+	ENEMY_ATTACK_WINDUP   = 0.3
+	ENEMY_ATTACK_COOLDOWN = 0.8
+	ENEMY_ATTACK_DAMAGE   = 1
+	ENEMY_HIT_STUN        = 0.3
+	// enemies with a projectile attack from this far instead of ENEMY_ATTACK_RANGE
+	ENEMY_SHOOT_RANGE = 64
+)
+
+// Enemy is a hostile character driven by a small state machine: idle and wander until the
+// player comes into sight, chase, attack when close, return home when the player gets away or
+// the chase leads too far from home, die at 0 HP.
+type Enemy struct {
+	*Character
+	State EnemyState
+	// Home is where the enemy was spawned
+	Home PointF
+	// Projectile makes the enemy attack from range, nil for melee
+	Projectile *ProjectileKind
+	// Removed is set once the death is over; the scene drops removed enemies
+	Removed bool
+
+	// seconds spent in the current state
+	stateTime float64
+	// how long the current idle or wander lasts
+	stateDuration float64
+	// unit vector of the current wander direction
+	wanderX, wanderY float64
+	// whether the current attack already hit
+	attackDone bool
+	// seconds the enemy stays stunned after being hit
+	stunTime float64
+}
+
+func NewEnemy(c *Character) *Enemy {
+	e := &Enemy{Character: c, State: ENEMY_STATE_IDLE, Home: c.Position, stateDuration: 1}
+	if c.Health.Max == 0 {
+		c.Health = NewHealth(ENEMY_DEFAULT_HP, 0)
+	}
+	if def := c.GameCharType.Def(); def != nil && def.Projectile != "" {
+		e.Projectile = ProjectileKinds[def.Projectile]
+	}
+	c.Health.OnDeath = func() {
+		e.setState(ENEMY_STATE_DIE)
+		e.Anim.PlayOnce(ANIM_DIE, func() { e.Removed = true })
+	}
+	return e
+}
+
+func (e *Enemy) setState(s EnemyState) {
+	e.State = s
+	e.stateTime = 0
+	e.attackDone = false
+}
+
+// IsAlive reports whether the enemy can still act and be hit.
+func (e *Enemy) IsAlive() bool {
+	return e.State != ENEMY_STATE_DIE
+}
+
+// TakeDamage removes HP, without knockback, and starts dying at 0. It returns whether the hit landed.
+func (e *Enemy) TakeDamage(amount int) bool {
+	if !e.IsAlive() {
+		return false
+	}
+	return e.Health.Damage(amount)
+}
+
+// Update runs one step of the enemy's state machine.
+func (e *Enemy) Update(p *PlayScene, delta float64) {
+	p.updateHealth(e.Character, delta)
+	if e.stunTime > 0 && e.IsAlive() {
+		e.stunTime -= delta
+		return
+	}
+	e.stateTime += delta
+
+	// vector and distance to the player
+	var toX, toY, dist float64
+	dist = math.Inf(1)
+	if p.Player != nil {
+		ex, ey := e.Center()
+		px, py := p.Player.Center()
+		toX, toY = px-ex, py-ey
+		dist = math.Hypot(toX, toY)
+	}
+
+	switch e.State {
+	case ENEMY_STATE_IDLE:
+		e.Play(ANIM_IDLE)
+		if dist <= ENEMY_SIGHT_RANGE {
+			e.setState(ENEMY_STATE_CHASE)
+		} else if e.stateTime >= e.stateDuration {
+			angle := p.rng.Float64() * 2 * math.Pi
+			e.wanderX, e.wanderY = math.Cos(angle), math.Sin(angle)
+			e.stateDuration = 1 + p.rng.Float64()
+			e.setState(ENEMY_STATE_WANDER)
+		}
+
+	case ENEMY_STATE_WANDER:
+		if dist <= ENEMY_SIGHT_RANGE {
+			e.setState(ENEMY_STATE_CHASE)
+			break
+		}
+		if e.stateTime >= e.stateDuration || !e.walk(p, e.wanderX, e.wanderY, e.MoveSpeed(ENEMY_CHASE_SPEED)*ENEMY_WANDER_SPEED_SCALE*delta) {
+			e.stateDuration = 1 + 2*p.rng.Float64()
+			e.setState(ENEMY_STATE_IDLE)
+		}
+
+	case ENEMY_STATE_CHASE:
+		switch {
+		case dist > ENEMY_LOSE_RANGE || e.distanceFromHome() > ENEMY_LEASH_RANGE:
+			e.setState(ENEMY_STATE_RETURN)
+		case dist <= e.attackRange():
+			e.SetFaceDir(faceDirTowards(toX, toY, e.GetFaceDir()))
+			e.Anim.PlayOnce(ANIM_ATTACK, nil)
+			e.setState(ENEMY_STATE_ATTACK)
+		default:
+			e.walk(p, toX/dist, toY/dist, e.MoveSpeed(ENEMY_CHASE_SPEED)*delta)
+		}
+
+	case ENEMY_STATE_ATTACK:
+		// wind up, strike once if the player is still in reach, then cool down before chasing again
+		if !e.attackDone && e.stateTime >= ENEMY_ATTACK_WINDUP {
+			e.attackDone = true
+			switch {
+			case dist > e.attackRange():
+			case e.Projectile != nil:
+				p.FireProjectile(e.Character, PROJECTILE_TEAM_ENEMY, e.Projectile, toX, toY)
+			default:
+				p.hurtPlayer(e.Character, ENEMY_ATTACK_DAMAGE)
+			}
+		}
+		if e.stateTime >= ENEMY_ATTACK_WINDUP+e.attackCooldown() {
+			e.setState(ENEMY_STATE_CHASE)
+		}
+
+	case ENEMY_STATE_RETURN:
+		// the player is ignored on the way back, so the enemy can't be dragged away from home again
+		d := e.distanceFromHome()
+		step := e.MoveSpeed(ENEMY_CHASE_SPEED) * delta
+		if d <= ENEMY_HOME_RANGE || !e.walk(p, (e.Home.X-e.Position.X)/d, (e.Home.Y-e.Position.Y)/d, min(step, d)) {
+			e.stateDuration = 1 + 2*p.rng.Float64()
+			e.setState(ENEMY_STATE_IDLE)
+		}
+
+	case ENEMY_STATE_DIE:
+		// Removed is set when the die clip ends
+	}
+}
+
+func (e *Enemy) distanceFromHome() float64 {
+	return math.Hypot(e.Position.X-e.Home.X, e.Position.Y-e.Home.Y)
+}
+
+func (e *Enemy) attackRange() float64 {
+	if e.Projectile != nil {
+		return ENEMY_SHOOT_RANGE
+	}
+	return ENEMY_ATTACK_RANGE
+}
+
+func (e *Enemy) attackCooldown() float64 {
+	if e.Projectile != nil {
+		return e.Projectile.Cooldown
+	}
+	return ENEMY_ATTACK_COOLDOWN
+}
+
+// walk moves the enemy along the unit vector dirX, dirY by dist pixels, facing where it goes.
+// It returns whether the enemy could move.
+func (e *Enemy) walk(p *PlayScene, dirX, dirY, dist float64) bool {
+	e.SetFaceDir(faceDirTowards(dirX, dirY, e.GetFaceDir()))
+	if !p.moveCharacter(e.Character, dirX*dist, dirY*dist) {
+		e.Play(ANIM_IDLE)
+		return false
+	}
+	e.Play(ANIM_WALK)
+	return true
+}
+
+// faceDirTowards returns the facing matching the larger component of dx, dy, or def when both are 0.
+func faceDirTowards(dx, dy float64, def int) int {
+	switch {
+	case dx == 0 && dy == 0:
+		return def
+	case math.Abs(dx) > math.Abs(dy):
+		if dx > 0 {
+			return FACE_DIR_RIGHT
+		}
+		return FACE_DIR_LEFT
+	case dy > 0:
+		return FACE_DIR_DOWN
+	default:
+		return FACE_DIR_UP
+	}
+}
+
+// updateEnemies runs every enemy and drops the ones whose death is over.
+func (p *PlayScene) updateEnemies(delta float64) {
+	kept := p.Enemies[:0]
+	for _, e := range p.Enemies {
+		e.Update(p, delta)
+		if !e.Removed {
+			kept = append(kept, e)
+		}
+	}
+	// clear the tail so removed enemies can be collected
+	for i := len(kept); i < len(p.Enemies); i++ {
+		p.Enemies[i] = nil
+	}
+	p.Enemies = kept
+}
+
+// hurtPlayer is called when an attack from source lands on the player: it takes the damage,
+// unless it's still invulnerable from a previous hit, and is knocked away from the attacker.
+func (p *PlayScene) hurtPlayer(source *Character, damage int) {
+	if p.Player == nil {
+		return
+	}
+	sx, sy := source.Center()
+	if p.Player.TakeHit(damage, sx, sy, PLAYER_KNOCKBACK) && !p.Player.Health.IsDead() {
+		p.Player.Anim.PlayOnce(ANIM_HURT, nil)
+	}
+}
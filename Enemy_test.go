@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestEnemyStateMachine(t *testing.T) {
+	// moves puts the player's sprite at x, y
+	moves := func(x, y float64) func(p *PlayScene, e *Enemy) {
+		return func(p *PlayScene, e *Enemy) { p.Player.Position = PointF{X: x, Y: y} }
+	}
+	// the player out of sight of an enemy at home at 40, 40
+	away := moves(170, 120)
+
+	type step struct {
+		do     func(p *PlayScene, e *Enemy)
+		frames int
+		want   EnemyState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"idle until the player is in sight", []step{
+			{away, 30, ENEMY_STATE_IDLE},
+			{moves(100, 40), 1, ENEMY_STATE_CHASE},
+			{nil, 10, ENEMY_STATE_CHASE},
+		}},
+		{"attack when close, then chase again", []step{
+			{moves(50, 40), 1, ENEMY_STATE_CHASE},
+			{nil, 1, ENEMY_STATE_ATTACK},
+			{func(p *PlayScene, e *Enemy) {
+				// hold the player in reach through the wind-up and the cooldown
+				p.Player.Position = PointF{X: 50, Y: 40}
+			}, int((ENEMY_ATTACK_WINDUP+ENEMY_ATTACK_COOLDOWN)/headlessFrame) - 1, ENEMY_STATE_ATTACK},
+			{moves(100, 40), 2, ENEMY_STATE_CHASE},
+		}},
+		{"losing aggro returns home", []step{
+			{moves(100, 40), 1, ENEMY_STATE_CHASE},
+			{nil, 20, ENEMY_STATE_CHASE},
+			{away, 1, ENEMY_STATE_RETURN},
+			{moves(60, 40), 5, ENEMY_STATE_RETURN},
+			{away, 60, ENEMY_STATE_IDLE},
+		}},
+		{"the leash returns home with the player in sight", []step{
+			{moves(100, 40), 1, ENEMY_STATE_CHASE},
+			{func(p *PlayScene, e *Enemy) {
+				// an enemy from the corner dragged far from home, with the player still close
+				e.Home = PointF{X: 8, Y: 8}
+				e.Position = PointF{X: 160, Y: 110}
+				p.Player.Position = PointF{X: 140, Y: 110}
+			}, 1, ENEMY_STATE_RETURN},
+			{nil, 30, ENEMY_STATE_RETURN},
+			// home is about 183 px away: back after some 3.3 s, before the idle of at least 1 s ends
+			{nil, 200, ENEMY_STATE_IDLE},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, p, _ := headlessScene(t)
+			e := NewEnemy(NewCharacter(PointF{X: 40, Y: 40}, GameCharacterSkeleton))
+			p.Enemies = []*Enemy{e}
+			for i, s := range tt.steps {
+				if s.do != nil {
+					s.do(p, e)
+				}
+				for range s.frames {
+					e.Update(p, headlessFrame)
+				}
+				if e.State != s.want {
+					t.Fatalf("step %d: state %d, want %d", i, e.State, s.want)
+				}
+			}
+			if e.State == ENEMY_STATE_IDLE && e.distanceFromHome() > ENEMY_HOME_RANGE {
+				t.Errorf("the enemy stopped %v from home", e.distanceFromHome())
+			}
+		})
+	}
+
+	// the attack hit the player held in reach
+	_, p, _ := headlessScene(t)
+	e := NewEnemy(NewCharacter(PointF{X: 40, Y: 40}, GameCharacterSkeleton))
+	p.Player.Position = PointF{X: 50, Y: 40}
+	hp := p.Player.Health.Current
+	for range int(ENEMY_ATTACK_WINDUP/headlessFrame) + 3 {
+		e.Update(p, headlessFrame)
+	}
+	if p.Player.Health.Current != hp-ENEMY_ATTACK_DAMAGE {
+		t.Errorf("player HP %d after the wind-up, want %d", p.Player.Health.Current, hp-ENEMY_ATTACK_DAMAGE)
+	}
+}
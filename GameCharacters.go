@@ -1,94 +1,268 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"image"
 	"log"
+	"os"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-// Additional GameCharacter constants (Player is defined in Player.go)
+// Characters defined in assets/characters.json that the code refers to directly
 const (
-	GameCharacterSkeleton GameCharacter = 1
+	GameCharacterSkeleton GameCharacter = "skeleton"
 )
 
 const (
 	SPRITE_DEFAULT_SIZE = 16
-	SPRITE_ROWS         = 7
-	SPRITE_COLS         = 4
+
+	// file the character registry is loaded from by LoadGameCharacters
+	CHARACTERS_PATH = "assets/characters.json"
+
+	// animation played while a character moves
+	ANIM_WALK = "walk"
+	ANIM_IDLE = "idle"
+
+	// seconds each frame of a clip without a frameTime shows
+	ANIM_DEFAULT_FRAME_TIME = 1.0 / 6
 )
 
-type CharacterSprites struct {
-	SpriteSheet *ebiten.Image
-	Sprites     [][]*ebiten.Image
+// AnimationDef is a clip: a run of frames along a sheet's frame axis.
+type AnimationDef struct {
+	Start  int `json:"start"`
+	Frames int `json:"frames"`
+	// FrameTime is how long each frame shows, in seconds; Durations overrides it per frame
+	FrameTime float64   `json:"frameTime"`
+	Durations []float64 `json:"durations"`
+	// OneShot clips play once, then switch to Next or hold their last frame when Next is empty
+	OneShot bool   `json:"oneShot"`
+	Next    string `json:"next"`
+}
+
+// FrameDuration returns how long frame i shows, in seconds.
+func (a AnimationDef) FrameDuration(i int) float64 {
+	if i >= 0 && i < len(a.Durations) && a.Durations[i] > 0 {
+		return a.Durations[i]
+	}
+	if a.FrameTime > 0 {
+		return a.FrameTime
+	}
+	return ANIM_DEFAULT_FRAME_TIME
+}
+
+// CharacterDef describes a character: its sprite sheet layout, animations and stats.
+//
+// Sheets hold one line of frames per direction. With DirectionAxis "column" (the default)
+// every direction is a column and its frames run down the rows; with "row" every direction
+// is a row and its frames run across the columns.
+type CharacterDef struct {
+	Name          string                  `json:"-"`
+	Sheet         string                  `json:"sheet"`
+	FrameWidth    int                     `json:"frameWidth"`
+	FrameHeight   int                     `json:"frameHeight"`
+	DirectionAxis string                  `json:"directionAxis"`
+	Directions    map[string]int          `json:"directions"`
+	Actions       map[string]AnimationDef `json:"actions"`
+	// Speed is the movement speed in pixels per second
+	Speed      float64 `json:"speed"`
+	HP         int     `json:"hp"`
+	InvulnTime float64 `json:"invulnTime"`
+	// Hitbox is relative to the top-left corner of the sprite
+	Hitbox *RectF `json:"hitbox"`
+	// Enemy characters can be spawned by map objects whose type is the character's name
+	Enemy bool `json:"enemy"`
+	// Projectile names the ProjectileKinds entry an enemy attacks with from range
+	Projectile string `json:"projectile"`
+
+	SpriteSheet *ebiten.Image `json:"-"`
+	// Sprites are indexed by FACE_DIR_* and then by frame
+	Sprites [][]*ebiten.Image `json:"-"`
+}
+
+// CharacterDefs is the character registry, filled by LoadGameCharacters.
+var CharacterDefs = make(map[GameCharacter]*CharacterDef)
+
+// faceDirNames maps the keys of CharacterDef.Directions to FACE_DIR_* values
+var faceDirNames = map[string]int{
+	"down":  FACE_DIR_DOWN,
+	"up":    FACE_DIR_UP,
+	"left":  FACE_DIR_LEFT,
+	"right": FACE_DIR_RIGHT,
+}
+
+// parseCharacterDefs decodes a character file: an object mapping character names to definitions.
+func parseCharacterDefs(data []byte) (map[GameCharacter]*CharacterDef, error) {
+	var raw map[string]*CharacterDef
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	defs := make(map[GameCharacter]*CharacterDef, len(raw))
+	for name, def := range raw {
+		if def == nil {
+			return nil, fmt.Errorf("character %q: empty definition", name)
+		}
+		def.Name = name
+		if def.FrameWidth <= 0 {
+			def.FrameWidth = SPRITE_DEFAULT_SIZE
+		}
+		if def.FrameHeight <= 0 {
+			def.FrameHeight = SPRITE_DEFAULT_SIZE
+		}
+		switch def.DirectionAxis {
+		case "":
+			def.DirectionAxis = "column"
+		case "column", "row":
+		default:
+			return nil, fmt.Errorf("character %q: directionAxis must be \"column\" or \"row\", not %q", name, def.DirectionAxis)
+		}
+		for dir := range def.Directions {
+			if _, ok := faceDirNames[dir]; !ok {
+				return nil, fmt.Errorf("character %q: unknown direction %q", name, dir)
+			}
+		}
+		for action, a := range def.Actions {
+			if a.Start < 0 || a.Frames <= 0 {
+				return nil, fmt.Errorf("character %q: action %q needs a start >= 0 and frames > 0", name, action)
+			}
+			if a.Next != "" {
+				if _, ok := def.Actions[a.Next]; !ok {
+					return nil, fmt.Errorf("character %q: action %q moves on to unknown action %q", name, action, a.Next)
+				}
+			}
+			if a.FrameTime <= 0 {
+				a.FrameTime = ANIM_DEFAULT_FRAME_TIME
+				def.Actions[action] = a
+			}
+		}
+		defs[GameCharacter(name)] = def
+	}
+	return defs, nil
 }
 
-var GameCharactersData = make(map[GameCharacter]*CharacterSprites)
+// LoadCharacterDefs reads a character file into the registry, replacing the definitions with the same name.
+// Sprite sheets are loaded separately, see LoadGameCharacters.
+func LoadCharacterDefs(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := parseCharacterDefs(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for gc, def := range defs {
+		CharacterDefs[gc] = def
+	}
+	return nil
+}
 
-// LoadGameCharacters reads sprite sheets from disk and slices them into frames.
-// It expects files at the paths provided in the map below. Change paths if needed.
+// LoadGameCharacters loads the character registry and slices every character's sprite sheet into frames.
+// Missing files are logged; characters without a sheet are simply not drawn.
 func LoadGameCharacters() {
-	files := map[GameCharacter]string{
-		GameCharacterPlayer:   "assets/playersheet.png",
-		GameCharacterSkeleton: "assets/skeletonsheet.png",
+	if err := LoadCharacterDefs(CHARACTERS_PATH); err != nil {
+		log.Printf("warning: could not load characters: %v", err)
 	}
 
-	for gc, path := range files {
-		sheet, _, err := ebitenutil.NewImageFromFile(path)
+	for _, def := range CharacterDefs {
+		if def.Sheet == "" {
+			continue
+		}
+		sheet, _, err := ebitenutil.NewImageFromFile(def.Sheet)
 		if err != nil {
-			// If file missing, log and continue with nil entry
-			log.Printf("warning: could not load %s: %v", path, err)
-			GameCharactersData[gc] = &CharacterSprites{}
+			// If file missing, log and continue without sprites
+			log.Printf("warning: could not load %s: %v", def.Sheet, err)
 			continue
 		}
+		def.setSheet(sheet)
+	}
+}
 
-		sprites := make([][]*ebiten.Image, SPRITE_ROWS)
-		for r := 0; r < SPRITE_ROWS; r++ {
-			sprites[r] = make([]*ebiten.Image, SPRITE_COLS)
-			for c := 0; c < SPRITE_COLS; c++ {
-				x0 := c * SPRITE_DEFAULT_SIZE
-				y0 := r * SPRITE_DEFAULT_SIZE
-				rect := image.Rect(x0, y0, x0+SPRITE_DEFAULT_SIZE, y0+SPRITE_DEFAULT_SIZE)
-				sub := sheet.SubImage(rect).(*ebiten.Image)
-				sprites[r][c] = sub
+// setSheet slices the sheet into one line of frames per direction.
+func (def *CharacterDef) setSheet(sheet *ebiten.Image) {
+	def.SpriteSheet = sheet
+	cols := sheet.Bounds().Dx() / def.FrameWidth
+	rows := sheet.Bounds().Dy() / def.FrameHeight
+	frameCount := rows
+	if def.DirectionAxis == "row" {
+		frameCount = cols
+	}
+
+	def.Sprites = make([][]*ebiten.Image, len(faceDirNames))
+	for name, faceDir := range faceDirNames {
+		line, ok := def.Directions[name]
+		if !ok {
+			// sheets without this direction use the first line
+			line = 0
+		}
+		frames := make([]*ebiten.Image, frameCount)
+		for i := range frames {
+			c, r := line, i
+			if def.DirectionAxis == "row" {
+				c, r = i, line
 			}
+			if c >= cols || r >= rows {
+				break
+			}
+			x0 := sheet.Bounds().Min.X + c*def.FrameWidth
+			y0 := sheet.Bounds().Min.Y + r*def.FrameHeight
+			rect := image.Rect(x0, y0, x0+def.FrameWidth, y0+def.FrameHeight)
+			frames[i] = sheet.SubImage(rect).(*ebiten.Image)
 		}
+		def.Sprites[faceDir] = frames
+	}
+}
 
-		GameCharactersData[gc] = &CharacterSprites{
-			SpriteSheet: sheet,
-			Sprites:     sprites,
-		}
+// Action returns the frames of an action, falling back to the walk animation and then to the first frame.
+func (def *CharacterDef) Action(action string) AnimationDef {
+	if a, ok := def.Actions[action]; ok {
+		return a
 	}
+	if a, ok := def.Actions[ANIM_WALK]; ok {
+		return a
+	}
+	return AnimationDef{Start: 0, Frames: 1}
 }
 
-func (gc GameCharacter) GetAnimationFrames(direction int) int {
-	// Default: 4 frames for current characters
-	switch gc {
-	case GameCharacterPlayer:
-		return 4
-	case GameCharacterSkeleton:
-		return 4
-	default:
-		return 1
+// Frame returns the sprite for the given frame of an action, wrapping around its frame count.
+func (def *CharacterDef) Frame(action string, frame, faceDir int) *ebiten.Image {
+	if faceDir < 0 || faceDir >= len(def.Sprites) {
+		return nil
+	}
+	a := def.Action(action)
+	i := a.Start + frame%a.Frames
+	frames := def.Sprites[faceDir]
+	if i < 0 || i >= len(frames) {
+		return nil
 	}
+	return frames[i]
+}
+
+// Def returns the registry entry of the character, or nil when it isn't defined.
+func (gc GameCharacter) Def() *CharacterDef {
+	return CharacterDefs[gc]
+}
+
+func (gc GameCharacter) GetAnimationFrames(action string) int {
+	if def := gc.Def(); def != nil {
+		return def.Action(action).Frames
+	}
+	return 1
 }
 
 func (gc GameCharacter) GetSpriteSheet() *ebiten.Image {
-	if data, ok := GameCharactersData[gc]; ok {
-		return data.SpriteSheet
+	if def := gc.Def(); def != nil {
+		return def.SpriteSheet
 	}
 	return nil
 }
 
-func (gc GameCharacter) GetSprite(yPos, xPos int) *ebiten.Image {
-	if data, ok := GameCharactersData[gc]; ok {
-		if yPos >= 0 && yPos < len(data.Sprites) {
-			row := data.Sprites[yPos]
-			if xPos >= 0 && xPos < len(row) {
-				return row[xPos]
-			}
-		}
+// GetSprite returns the frame aniIndex of an action facing faceDir.
+func (gc GameCharacter) GetSprite(action string, aniIndex, faceDir int) *ebiten.Image {
+	if def := gc.Def(); def != nil {
+		return def.Frame(action, aniIndex, faceDir)
 	}
 	return nil
 }
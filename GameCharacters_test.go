@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestCharacterFileIsValid(t *testing.T) {
+	data, err := os.ReadFile(CHARACTERS_PATH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, err := parseCharacterDefs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gc := range []GameCharacter{GameCharacterPlayer, GameCharacterSkeleton} {
+		def, ok := defs[gc]
+		if !ok {
+			t.Errorf("%s is not defined", gc)
+			continue
+		}
+		if _, err := os.Stat(def.Sheet); err != nil {
+			t.Errorf("%s: %v", gc, err)
+		}
+	}
+}
+
+func TestCharacterSheetLayout(t *testing.T) {
+	defs, err := parseCharacterDefs([]byte(`{
+		"columns": {"directions": {"down": 0, "up": 1, "left": 2, "right": 3}, "actions": {"walk": {"start": 1, "frames": 3}}},
+		"rows": {"frameWidth": 8, "frameHeight": 8, "directionAxis": "row", "directions": {"down": 2, "left": 1}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := ebiten.NewImage(64, 112)
+
+	cols := defs["columns"]
+	cols.setSheet(sheet)
+	// frame 4 of the walk wraps to its second frame, row 2 of the right column
+	if got, want := cols.Frame(ANIM_WALK, 4, FACE_DIR_RIGHT).Bounds(), image.Rect(48, 32, 64, 48); got != want {
+		t.Errorf("column layout frame = %v, want %v", got, want)
+	}
+	if cols.Actions[ANIM_WALK].FrameTime != ANIM_DEFAULT_FRAME_TIME || cols.FrameWidth != SPRITE_DEFAULT_SIZE {
+		t.Error("defaults should be applied")
+	}
+
+	rows := defs["rows"]
+	rows.setSheet(sheet)
+	if got, want := rows.Frame(ANIM_IDLE, 0, FACE_DIR_LEFT).Bounds(), image.Rect(0, 8, 8, 16); got != want {
+		t.Errorf("row layout frame = %v, want %v", got, want)
+	}
+	// directions missing from the file use the first line
+	if got, want := rows.Frame(ANIM_IDLE, 0, FACE_DIR_UP).Bounds(), image.Rect(0, 0, 8, 8); got != want {
+		t.Errorf("missing direction frame = %v, want %v", got, want)
+	}
+
+	if _, err := parseCharacterDefs([]byte(`{"bad": {"directionAxis": "diagonal"}}`)); err == nil {
+		t.Error("an unknown direction axis should be rejected")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// GameOverScene is shown when the player dies: Confirm restarts the map, Cancel goes back to the menu.
+type GameOverScene struct {
+	sm      *SceneManager
+	mapPath string
+}
+
+func NewGameOverScene(sm *SceneManager, mapPath string) *GameOverScene {
+	return &GameOverScene{sm: sm, mapPath: mapPath}
+}
+
+func (g *GameOverScene) Enter()  {}
+func (g *GameOverScene) Exit()   {}
+func (g *GameOverScene) Pause()  {}
+func (g *GameOverScene) Resume() {}
+
+func (g *GameOverScene) Update() error {
+	actions := g.sm.actions()
+	switch {
+	case actions.JustPressed(ActionConfirm):
+		g.sm.GoToWith(NewPlaySceneWithMap(g.sm, g.mapPath), Transition{Kind: TRANSITION_FADE, Duration: TRANSITION_FADE_TIME})
+	case actions.JustPressed(ActionCancel):
+		g.sm.GoToWith(NewMenuScene(g.sm), Transition{Kind: TRANSITION_CROSSFADE, Duration: TRANSITION_FADE_TIME})
+	}
+	return nil
+}
+
+func (g *GameOverScene) Draw(screen *ebiten.Image) {
+	DrawTextAtCenter(screen, "Game Over")
+	hint := "ENTER to retry - ESC for menu"
+	ebitenutil.DebugPrintAt(screen, hint, (screen.Bounds().Dx()-len(hint)*6)/2, screen.Bounds().Dy()/2+20)
+}
+
+func (g *GameOverScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return 320, 128
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// GamepadTracker keeps the list of connected gamepads that have a standard layout mapping.
+// Gamepads without one are logged and ignored, since bindings name standard buttons.
+type GamepadTracker struct {
+	ids []ebiten.GamepadID
+	buf []ebiten.GamepadID
+}
+
+// Gamepads is updated by EbitenInput at the end of every frame.
+var Gamepads GamepadTracker
+
+// Update picks up the gamepads connected and disconnected during the frame.
+func (g *GamepadTracker) Update() {
+	g.buf = inpututil.AppendJustConnectedGamepadIDs(g.buf[:0])
+	for _, id := range g.buf {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			log.Printf("warning: gamepad %q has no standard layout mapping and is ignored", ebiten.GamepadName(id))
+			continue
+		}
+		log.Printf("gamepad connected: %s", ebiten.GamepadName(id))
+		g.ids = append(g.ids, id)
+	}
+	g.ids = slices.DeleteFunc(g.ids, func(id ebiten.GamepadID) bool {
+		if inpututil.IsGamepadJustDisconnected(id) {
+			log.Printf("gamepad %d disconnected", id)
+			return true
+		}
+		return false
+	})
+}
+
+// IDs returns the usable gamepads. The slice must not be modified.
+func (g *GamepadTracker) IDs() []ebiten.GamepadID {
+	return g.ids
+}
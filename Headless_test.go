@@ -0,0 +1,338 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const headlessFrame = 1.0 / 60
+
+func headlessFrameTime() float64 { return headlessFrame }
+
+// headlessMap is a 20x10 open map with a solid column at tile x = 12.
+func headlessMap() *TilemapJSON {
+	tm := syntheticTilemap(20, 10)
+	for i := range tm.Layers[0].Data {
+		tm.Layers[0].Data[i] = 1
+		if i%20 == 12 {
+			tm.Layers[0].Data[i] = 2
+		}
+	}
+	tm.Tilesets[0].Tiles = []TilesetTileJSON{{ID: 1, Properties: TiledProperties{{Name: "solid", Type: "bool", Value: true}}}}
+	return tm
+}
+
+// headlessManager is a scene manager driven by in that saves to and continues from a temporary
+// file, so no test sees or changes the player's own save.
+func headlessManager(t *testing.T, in Input) *SceneManager {
+	t.Helper()
+	return &SceneManager{Input: in, FrameTime: headlessFrameTime, SavePath: filepath.Join(t.TempDir(), "save.json")}
+}
+
+// headlessGame starts a game on the menu like NewGameWithInput, on a headlessManager.
+func headlessGame(t *testing.T, in Input) *Game {
+	t.Helper()
+	g := &Game{manager: headlessManager(t, in)}
+	g.manager.GoTo(NewMenuScene(g.manager))
+	return g
+}
+
+// headlessScene runs a PlayScene on headlessMap driven by scripted input.
+// The player starts at 32, 64 facing right.
+func headlessScene(t *testing.T) (*SceneManager, *PlayScene, *ScriptedInput) {
+	t.Helper()
+	in := NewScriptedInput()
+	sm := headlessManager(t, in)
+	p := NewPlaySceneWithTilemap(sm, headlessMap(), DEFAULT_MAP_PATH)
+	p.Player.Position = PointF{X: 32, Y: 64}
+	p.Player.SetFaceDir(FACE_DIR_RIGHT)
+	sm.GoTo(p)
+	return sm, p, in
+}
+
+func runFrames(t *testing.T, sm *SceneManager, frames int) {
+	t.Helper()
+	for i := 0; i < frames; i++ {
+		if err := sm.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// finishTransition runs frames until the scene transition in progress, if any, ends.
+func finishTransition(t *testing.T, sm *SceneManager) {
+	t.Helper()
+	for i := 0; sm.Transitioning(); i++ {
+		if i > 600 {
+			t.Fatal("the transition never ended")
+		}
+		runFrames(t, sm, 1)
+	}
+}
+
+func TestHeadlessMovement(t *testing.T) {
+	sm, p, in := headlessScene(t)
+
+	in.Press(ebiten.KeyD)
+	runFrames(t, sm, 20)
+	// 20 steps at PLAYER_SPEED px/s
+	want := 32 + PLAYER_SPEED*20*headlessFrame
+	if got := p.Player.Position.X; got < want-0.01 || got > want+0.01 {
+		t.Errorf("after 20 frames x = %v, want %v", got, want)
+	}
+	if p.Player.Position.Y != 64 {
+		t.Errorf("moving right changed y to %v", p.Player.Position.Y)
+	}
+
+	in.Release(ebiten.KeyD)
+	x := p.Player.Position.X
+	runFrames(t, sm, 10)
+	if p.Player.Position.X != x {
+		t.Error("the player kept moving after the key was released")
+	}
+}
+
+func TestHeadlessCollision(t *testing.T) {
+	sm, p, in := headlessScene(t)
+
+	in.Press(ebiten.KeyD)
+	runFrames(t, sm, 120)
+	hb := p.Player.HitboxAt(p.Player.Position.X, p.Player.Position.Y)
+	if right := hb.X + hb.W; right > 12*TILE_SIZE || right < 12*TILE_SIZE-4 {
+		t.Errorf("hitbox right edge at %v, want it stopped against the wall at %d", right, 12*TILE_SIZE)
+	}
+
+	// sliding: moving diagonally into the wall still moves along it
+	y := p.Player.Position.Y
+	in.Press(ebiten.KeyS)
+	runFrames(t, sm, 10)
+	if p.Player.Position.Y <= y {
+		t.Error("the player should slide down along the wall")
+	}
+}
+
+func TestHeadlessMeleeCombat(t *testing.T) {
+	sm, p, in := headlessScene(t)
+	e := NewEnemy(NewCharacter(PointF{X: 46, Y: 64}, GameCharacterSkeleton))
+	p.Enemies = append(p.Enemies, e)
+	hp := e.Health.Current
+
+	in.Press(ebiten.KeyF)
+	runFrames(t, sm, 2)
+	if got, want := e.Health.Current, hp-p.Player.Weapon.Damage; got != want {
+		t.Fatalf("enemy HP %d after a swing, want %d", got, want)
+	}
+	if e.Position.X <= 46 {
+		t.Error("the enemy should be knocked away from the player")
+	}
+
+	// holding the key doesn't hit again within the same swing
+	runFrames(t, sm, 5)
+	if got, want := e.Health.Current, hp-p.Player.Weapon.Damage; got != want {
+		t.Errorf("enemy HP %d after holding the attack, want %d", got, want)
+	}
+}
+
+func TestHeadlessSceneTransitions(t *testing.T) {
+	LoadButtons()
+	in := NewScriptedInput()
+	g := headlessGame(t, in)
+	sm := g.manager
+
+	// click Play
+	start := sm.Current().(*MenuScene).StartButton.Bounds()
+	in.MoveCursor(start.Min.X+4, start.Min.Y+4)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	g.Update()
+	in.ReleaseMouse(ebiten.MouseButtonLeft)
+	g.Update()
+	p, ok := sm.Current().(*PlayScene)
+	if !ok {
+		t.Fatalf("clicking Play should start the game, scene is %T", sm.Current())
+	}
+	// the game holds still during the fade in
+	finishTransition(t, sm)
+
+	// dying leads to the game over scene after GAME_OVER_DELAY
+	p.Player.TakeHit(p.Player.Health.Max, 0, 0, 0)
+	runFrames(t, sm, int(GAME_OVER_DELAY/headlessFrame)+2)
+	if _, ok := sm.Current().(*GameOverScene); !ok {
+		t.Fatalf("after dying the scene is %T, want *GameOverScene", sm.Current())
+	}
+	// the input is blocked until the transition ends
+	in.Press(ebiten.KeyEnter)
+	runFrames(t, sm, 1)
+	in.Release(ebiten.KeyEnter)
+	if _, ok := sm.Current().(*GameOverScene); !ok {
+		t.Fatal("Enter should be ignored during the transition")
+	}
+	finishTransition(t, sm)
+
+	// Enter retries, Escape pauses the run and closes the pause menu again
+	in.Press(ebiten.KeyEnter)
+	runFrames(t, sm, 1)
+	in.Release(ebiten.KeyEnter)
+	p, ok = sm.Current().(*PlayScene)
+	if !ok {
+		t.Fatalf("Enter should restart the map, scene is %T", sm.Current())
+	}
+	finishTransition(t, sm)
+	in.Press(ebiten.KeyEscape)
+	runFrames(t, sm, 2)
+	in.Release(ebiten.KeyEscape)
+	runFrames(t, sm, 1)
+	if _, ok := sm.Current().(*PauseScene); !ok {
+		t.Fatalf("Escape should pause the game, scene is %T", sm.Current())
+	}
+	if sm.Below(sm.Current()) != Scene(p) {
+		t.Fatal("the run should wait beneath the pause menu")
+	}
+	in.Press(ebiten.KeyEscape)
+	runFrames(t, sm, 1)
+	in.Release(ebiten.KeyEscape)
+	if sm.Current() != Scene(p) || sm.Depth() != 1 {
+		t.Fatalf("Escape in the pause menu should resume the run, scene is %T at depth %d", sm.Current(), sm.Depth())
+	}
+}
+
+func TestHeadlessPauseMenu(t *testing.T) {
+	sm, p, in := headlessScene(t)
+
+	// the game time stops while the pause menu is open, even with a key held
+	in.Press(ebiten.KeyEscape)
+	runFrames(t, sm, 1)
+	in.Release(ebiten.KeyEscape)
+	pause, ok := sm.Current().(*PauseScene)
+	if !ok {
+		t.Fatalf("Escape should pause the game, scene is %T", sm.Current())
+	}
+	ticks, pos := p.Clock.Ticks, p.Player.Position
+	in.Press(ebiten.KeyD)
+	runFrames(t, sm, 30)
+	in.Release(ebiten.KeyD)
+	if p.Clock.Ticks != ticks || p.Player.Position != pos {
+		t.Fatal("the game kept running under the pause menu")
+	}
+
+	// clicking Resume goes back to the game, which runs again
+	r := pause.ResumeButton.Bounds()
+	in.MoveCursor(r.Min.X+2, r.Min.Y+2)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	in.ReleaseMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	if sm.Current() != Scene(p) {
+		t.Fatalf("clicking Resume should resume the game, scene is %T", sm.Current())
+	}
+	if p.Clock.Paused {
+		t.Fatal("the clock is still paused after resuming")
+	}
+	runFrames(t, sm, 1)
+	if p.Clock.Ticks == ticks {
+		t.Error("the game time didn't run again after resuming")
+	}
+
+	// Quit to Menu is the last entry
+	in.Press(ebiten.KeyEscape)
+	runFrames(t, sm, 1)
+	in.Release(ebiten.KeyEscape)
+	for i := 0; i < 3; i++ {
+		in.Press(ebiten.KeyS)
+		runFrames(t, sm, 1)
+		in.Release(ebiten.KeyS)
+		runFrames(t, sm, 1)
+	}
+	in.Press(ebiten.KeyEnter)
+	runFrames(t, sm, 1)
+	if _, ok := sm.Current().(*MenuScene); !ok || sm.Depth() != 1 {
+		t.Fatalf("Quit to Menu should leave the game, scene is %T at depth %d", sm.Current(), sm.Depth())
+	}
+}
+
+func TestHeadlessAnalogMovement(t *testing.T) {
+	sm, p, in := headlessScene(t)
+
+	// a half pushed stick moves slower than the keyboard, at its own angle
+	in.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, 0.6)
+	in.SetAxis(ebiten.StandardGamepadAxisLeftStickVertical, 0.3)
+	start := p.Player.Position
+	runFrames(t, sm, 10)
+	dx, dy := p.Player.Position.X-start.X, p.Player.Position.Y-start.Y
+	full := PLAYER_SPEED * 10 * headlessFrame
+	if d := math.Hypot(dx, dy); d <= 0 || d >= full*0.7 {
+		t.Errorf("moved %v px, want less than the keyboard's %v", d, full)
+	}
+	if ratio := dy / dx; math.Abs(ratio-0.5) > 1e-6 {
+		t.Errorf("moved along %v, want the stick's 0.5 slope", ratio)
+	}
+	if p.Player.GetFaceDir() != FACE_DIR_RIGHT {
+		t.Error("the player should face the larger component of the stick")
+	}
+}
+
+func TestHeadlessMenuNavigation(t *testing.T) {
+	in := NewScriptedInput()
+	g := headlessGame(t, in)
+	m := g.manager.Current().(*MenuScene)
+	if !m.ContinueButton.Disabled {
+		t.Fatal("there should be no game to continue in a fresh save file")
+	}
+
+	// without a save Continue is disabled and skipped: down to Exit and back up to Start with the d-pad, then A
+	for _, b := range []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftBottom, ebiten.StandardGamepadButtonLeftTop} {
+		in.PressGamepad(b)
+		g.Update()
+		in.ReleaseGamepad(b)
+		g.Update()
+	}
+	if m.ui.Focused() != ui.Focusable(m.StartButton) || !m.ui.FocusVisible {
+		t.Fatal("want the start button focused and outlined")
+	}
+	in.PressGamepad(ebiten.StandardGamepadButtonRightBottom)
+	g.Update()
+	if _, ok := g.manager.Current().(*PlayScene); !ok {
+		t.Errorf("confirming Start should start the game, scene is %T", g.manager.Current())
+	}
+}
+
+func TestHeadlessLoadMapTrigger(t *testing.T) {
+	sm, p, in := headlessScene(t)
+	p.Player.Health.Current = 2
+	p.Player.Inventory["coin"] = 4
+	p.Player.Weapon = Weapons["goldsword"]
+	loads := 0
+	RegisterTriggerAction("testloadmap", func(p *PlayScene, t *Trigger) {
+		loads++
+		loadMapTrigger(p, t)
+	})
+	t.Cleanup(func() { delete(triggerActions, "testloadmap") })
+	// two overlapping triggers just right of the player; only the first may switch maps
+	for id := 1; id <= 2; id++ {
+		obj := &TiledObjectJSON{ID: id, X: 56, Y: 0, Width: 32, Height: 160,
+			Properties: TiledProperties{{Name: "map", Type: "string", Value: "dirtmap.json"}}}
+		p.Triggers = append(p.Triggers, &Trigger{Object: obj, Action: "testloadmap"})
+	}
+
+	in.Press(ebiten.KeyD)
+	for i := 0; sm.Current() == Scene(p); i++ {
+		if i > 120 {
+			t.Fatal("the player never reached the trigger")
+		}
+		runFrames(t, sm, 1)
+	}
+	next := sm.Current().(*PlayScene)
+	if next.mapPath != DEFAULT_MAP_PATH {
+		t.Errorf("loaded %q, want %q", next.mapPath, DEFAULT_MAP_PATH)
+	}
+	if loads != 1 {
+		t.Errorf("the triggers loaded %d maps, want 1", loads)
+	}
+	pl := next.Player
+	if pl.Health.Current != 2 || pl.Inventory["coin"] != 4 || pl.Weapon != Weapons["goldsword"] {
+		t.Errorf("the player arrived with %d HP, inventory %v and weapon %v", pl.Health.Current, pl.Inventory, pl.Weapon)
+	}
+}
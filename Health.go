@@ -0,0 +1,119 @@
+package main
+
+import "math"
+
+const (
+	// how long a character is tinted after being hit, in seconds
+	HIT_FLASH_TIME = 0.2
+	// knockback velocity decays by this factor per second; an impulse of distance d starts at
+	// d * KNOCKBACK_DECAY pixels per second so the character travels about d pixels in total
+	KNOCKBACK_DECAY = 12
+	// knockback below this speed (pixels per second) stops
+	KNOCKBACK_MIN_SPEED = 4
+)
+
+// Health tracks a character's hit points, the invulnerability window after a hit and the
+// knockback being applied to it. The zero value is a character that can't be hurt.
+type Health struct {
+	Max     int
+	Current int
+	// InvulnDuration is how long hits are ignored after taking one, in seconds
+	InvulnDuration float64
+	// OnDeath is called once, when Current reaches 0
+	OnDeath func()
+
+	invulnTime float64
+	// knockback velocity in pixels per second
+	knockX, knockY float64
+}
+
+func NewHealth(max int, invulnDuration float64) Health {
+	return Health{Max: max, Current: max, InvulnDuration: invulnDuration}
+}
+
+// SetMax changes the maximum HP and refills the character.
+func (h *Health) SetMax(max int) {
+	h.Max = max
+	h.Current = max
+}
+
+func (h *Health) IsDead() bool {
+	return h.Max > 0 && h.Current <= 0
+}
+
+func (h *Health) IsInvulnerable() bool {
+	return h.invulnTime > 0
+}
+
+// Heal adds HP up to Max. The dead can't be healed.
+func (h *Health) Heal(amount int) {
+	if h.IsDead() {
+		return
+	}
+	h.Current = min(h.Current+amount, h.Max)
+}
+
+// Damage removes HP and starts the invulnerability window. It returns false when the hit was
+// ignored because the character can't be hurt, is invulnerable or is already dead.
+func (h *Health) Damage(amount int) bool {
+	if h.Max <= 0 || h.IsDead() || h.IsInvulnerable() {
+		return false
+	}
+	h.Current -= amount
+	h.invulnTime = h.InvulnDuration
+	if h.Current <= 0 {
+		h.Current = 0
+		if h.OnDeath != nil {
+			h.OnDeath()
+		}
+	}
+	return true
+}
+
+// TakeHit damages the character, flashes it and knocks it about knockback pixels away from
+// fromX, fromY. It returns whether the hit landed.
+func (c *Character) TakeHit(damage int, fromX, fromY, knockback float64) bool {
+	if !c.Health.Damage(damage) {
+		return false
+	}
+	c.FlashTime = HIT_FLASH_TIME
+	c.Knockback(fromX, fromY, knockback)
+	return true
+}
+
+// Knockback pushes the character about distance pixels away from fromX, fromY over the next updates.
+func (c *Character) Knockback(fromX, fromY, distance float64) {
+	cx, cy := c.Center()
+	dx, dy := cx-fromX, cy-fromY
+	d := math.Hypot(dx, dy)
+	if d == 0 || distance <= 0 {
+		return
+	}
+	c.Health.knockX = dx / d * distance * KNOCKBACK_DECAY
+	c.Health.knockY = dy / d * distance * KNOCKBACK_DECAY
+}
+
+// updateHealth counts down the hit flash and i-frames of c and moves it along its knockback,
+// through the map's collision.
+func (p *PlayScene) updateHealth(c *Character, delta float64) {
+	h := &c.Health
+	if c.FlashTime > 0 {
+		c.FlashTime -= delta
+	}
+	if h.invulnTime > 0 {
+		h.invulnTime -= delta
+	}
+	if h.knockX == 0 && h.knockY == 0 {
+		return
+	}
+	if !p.moveCharacter(c, h.knockX*delta, h.knockY*delta) {
+		h.knockX, h.knockY = 0, 0
+		return
+	}
+	decay := math.Exp(-KNOCKBACK_DECAY * delta)
+	h.knockX *= decay
+	h.knockY *= decay
+	if math.Hypot(h.knockX, h.knockY) < KNOCKBACK_MIN_SPEED {
+		h.knockX, h.knockY = 0, 0
+	}
+}
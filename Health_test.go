@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestHealthInvulnerabilityAndDeath(t *testing.T) {
+	h := NewHealth(3, 0.5)
+	deaths := 0
+	h.OnDeath = func() { deaths++ }
+
+	if !h.Damage(1) || h.Current != 2 {
+		t.Fatalf("first hit should land, HP %d", h.Current)
+	}
+	if h.Damage(1) {
+		t.Error("a hit during the invulnerability window should be ignored")
+	}
+	h.invulnTime = 0
+	if !h.Damage(5) || h.Current != 0 || !h.IsDead() {
+		t.Errorf("lethal hit should leave 0 HP and dead, HP %d", h.Current)
+	}
+	h.invulnTime = 0
+	if h.Damage(1) {
+		t.Error("the dead can't be hit")
+	}
+	if deaths != 1 {
+		t.Errorf("OnDeath called %d times, want 1", deaths)
+	}
+
+	var zero Health
+	if zero.Damage(1) || zero.IsDead() {
+		t.Error("a character without health can't be hurt")
+	}
+}
+
+func TestKnockbackStopsAtWalls(t *testing.T) {
+	p := projectileScene()
+	c := NewCharacter(PointF{X: 100, Y: 40}, GameCharacterSkeleton)
+	c.Health = NewHealth(5, 0)
+	// hit from the left, pushing towards the solid column at x 128..144
+	if !c.TakeHit(1, 0, c.Position.Y+10, 40) {
+		t.Fatal("hit should land")
+	}
+	for i := 0; i < 120; i++ {
+		p.updateHealth(c, 1.0/60)
+	}
+	if c.Position.X <= 100 {
+		t.Errorf("character should be pushed right, x %v", c.Position.X)
+	}
+	if hb := c.HitboxAt(c.Position.X, c.Position.Y); hb.X+hb.W > 128 {
+		t.Errorf("knockback pushed the hitbox into the wall: %+v", hb)
+	}
+	if c.FlashTime > 0 {
+		t.Error("the hit flash should be over")
+	}
+}
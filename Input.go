@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Input is where scenes read the keyboard and mouse from. The game uses ebiten's state;
+// tests and headless runs inject a ScriptedInput instead.
+type Input interface {
+	IsKeyPressed(key ebiten.Key) bool
+	IsKeyJustPressed(key ebiten.Key) bool
+	IsMouseButtonPressed(button ebiten.MouseButton) bool
+	// CursorPosition is on the logical screen like ebiten's
+	CursorPosition() (int, int)
+	// IsGamepadButtonPressed and GamepadAxisValue read the connected gamepads with a standard layout
+	IsGamepadButtonPressed(button ebiten.StandardGamepadButton) bool
+	GamepadAxisValue(axis ebiten.StandardGamepadAxis) float64
+	// EndFrame is called by the SceneManager after every update
+	EndFrame()
+}
+
+// EbitenInput reads the real input devices through ebiten. Gamepads are read from Gamepads.
+type EbitenInput struct{}
+
+func (EbitenInput) IsKeyPressed(key ebiten.Key) bool {
+	return ebiten.IsKeyPressed(key)
+}
+
+func (EbitenInput) IsKeyJustPressed(key ebiten.Key) bool {
+	return inpututil.IsKeyJustPressed(key)
+}
+
+func (EbitenInput) IsMouseButtonPressed(button ebiten.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(button)
+}
+
+func (EbitenInput) CursorPosition() (int, int) {
+	return ebiten.CursorPosition()
+}
+
+// IsGamepadButtonPressed reports whether the button is held on any gamepad.
+func (EbitenInput) IsGamepadButtonPressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range Gamepads.IDs() {
+		if ebiten.IsStandardGamepadButtonPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// GamepadAxisValue returns the axis of the gamepad pushing it the furthest.
+func (EbitenInput) GamepadAxisValue(axis ebiten.StandardGamepadAxis) float64 {
+	v := 0.0
+	for _, id := range Gamepads.IDs() {
+		if a := ebiten.StandardGamepadAxisValue(id, axis); math.Abs(a) > math.Abs(v) {
+			v = a
+		}
+	}
+	return v
+}
+
+func (EbitenInput) EndFrame() {
+	Gamepads.Update()
+}
+
+// noInput is an Input with nothing pressed, which scenes read during transitions.
+type noInput struct{}
+
+func (noInput) IsKeyPressed(ebiten.Key) bool                             { return false }
+func (noInput) IsKeyJustPressed(ebiten.Key) bool                         { return false }
+func (noInput) IsMouseButtonPressed(ebiten.MouseButton) bool             { return false }
+func (noInput) CursorPosition() (int, int)                               { return -1, -1 }
+func (noInput) IsGamepadButtonPressed(ebiten.StandardGamepadButton) bool { return false }
+func (noInput) GamepadAxisValue(ebiten.StandardGamepadAxis) float64      { return 0 }
+func (noInput) EndFrame()                                                {}
+
+// ScriptedInput is an Input driven by code: keys and buttons stay down from Press until Release.
+type ScriptedInput struct {
+	keys     map[ebiten.Key]bool
+	prevKeys map[ebiten.Key]bool
+	buttons  map[ebiten.MouseButton]bool
+	pad      map[ebiten.StandardGamepadButton]bool
+	axes     map[ebiten.StandardGamepadAxis]float64
+	cursorX  int
+	cursorY  int
+}
+
+func NewScriptedInput() *ScriptedInput {
+	return &ScriptedInput{
+		keys:     make(map[ebiten.Key]bool),
+		prevKeys: make(map[ebiten.Key]bool),
+		buttons:  make(map[ebiten.MouseButton]bool),
+		pad:      make(map[ebiten.StandardGamepadButton]bool),
+		axes:     make(map[ebiten.StandardGamepadAxis]float64),
+	}
+}
+
+func (s *ScriptedInput) Press(keys ...ebiten.Key) {
+	for _, k := range keys {
+		s.keys[k] = true
+	}
+}
+
+func (s *ScriptedInput) Release(keys ...ebiten.Key) {
+	for _, k := range keys {
+		delete(s.keys, k)
+	}
+}
+
+// ReleaseAll lets go of every key and mouse button.
+func (s *ScriptedInput) ReleaseAll() {
+	clear(s.keys)
+	clear(s.buttons)
+	clear(s.pad)
+	clear(s.axes)
+}
+
+func (s *ScriptedInput) PressGamepad(button ebiten.StandardGamepadButton) {
+	s.pad[button] = true
+}
+
+func (s *ScriptedInput) ReleaseGamepad(button ebiten.StandardGamepadButton) {
+	delete(s.pad, button)
+}
+
+// SetAxis moves a gamepad axis, from -1 to 1.
+func (s *ScriptedInput) SetAxis(axis ebiten.StandardGamepadAxis, v float64) {
+	s.axes[axis] = v
+}
+
+func (s *ScriptedInput) PressMouse(button ebiten.MouseButton) {
+	s.buttons[button] = true
+}
+
+func (s *ScriptedInput) ReleaseMouse(button ebiten.MouseButton) {
+	delete(s.buttons, button)
+}
+
+func (s *ScriptedInput) MoveCursor(x, y int) {
+	s.cursorX, s.cursorY = x, y
+}
+
+func (s *ScriptedInput) IsKeyPressed(key ebiten.Key) bool {
+	return s.keys[key]
+}
+
+func (s *ScriptedInput) IsKeyJustPressed(key ebiten.Key) bool {
+	return s.keys[key] && !s.prevKeys[key]
+}
+
+func (s *ScriptedInput) IsMouseButtonPressed(button ebiten.MouseButton) bool {
+	return s.buttons[button]
+}
+
+func (s *ScriptedInput) CursorPosition() (int, int) {
+	return s.cursorX, s.cursorY
+}
+
+func (s *ScriptedInput) IsGamepadButtonPressed(button ebiten.StandardGamepadButton) bool {
+	return s.pad[button]
+}
+
+func (s *ScriptedInput) GamepadAxisValue(axis ebiten.StandardGamepadAxis) float64 {
+	return s.axes[axis]
+}
+
+func (s *ScriptedInput) EndFrame() {
+	clear(s.prevKeys)
+	for k := range s.keys {
+		s.prevKeys[k] = true
+	}
+}
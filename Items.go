@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// item icons are large images drawn down to this size in the world
+const ITEM_DRAW_SIZE = 16
+
+// ItemImages holds the icon of every known item kind, see LoadItems.
+var ItemImages = make(map[string]*ebiten.Image)
+
+// LoadItems loads the item icons. Missing files are logged and leave the item without an icon.
+func LoadItems() {
+	files := map[string]string{
+		"diamond": "assets/diamond.png",
+	}
+
+	for kind, path := range files {
+		img, _, err := ebitenutil.NewImageFromFile(path)
+		if err != nil {
+			log.Printf("warning: could not load %s: %v", path, err)
+			ItemImages[kind] = nil
+			continue
+		}
+		ItemImages[kind] = img
+	}
+}
+
+// Item is something lying in the world that the player picks up by walking over it.
+type Item struct {
+	Kind     string
+	Position PointF
+	Hitbox   RectF
+}
+
+// NewItem returns an item of a known kind, or nil when the kind has never been loaded.
+func NewItem(kind string, pos PointF) *Item {
+	if _, ok := ItemImages[kind]; !ok {
+		return nil
+	}
+	return &Item{
+		Kind:     kind,
+		Position: pos,
+		Hitbox:   RectF{X: 2, Y: 2, W: ITEM_DRAW_SIZE - 4, H: ITEM_DRAW_SIZE - 4},
+	}
+}
+
+func (it *Item) Bounds() RectF {
+	return it.Hitbox.Offset(it.Position.X, it.Position.Y)
+}
+
+func (it *Item) Draw(screen *ebiten.Image, camera *Camera) {
+	img := ItemImages[it.Kind]
+	if img == nil {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(ITEM_DRAW_SIZE/float64(img.Bounds().Dx()), ITEM_DRAW_SIZE/float64(img.Bounds().Dy()))
+	op.GeoM.Translate(it.Position.X, it.Position.Y)
+	if camera != nil {
+		op.GeoM.Translate(-camera.X, -camera.Y)
+	}
+	screen.DrawImage(img, op)
+}
+
+// updateItems moves the items the player touches into the player's inventory.
+func (p *PlayScene) updateItems() {
+	if p.Player == nil {
+		return
+	}
+	hb := p.Player.HitboxAt(p.Player.Position.X, p.Player.Position.Y)
+	kept := p.Items[:0]
+	for _, it := range p.Items {
+		if hb.Intersects(it.Bounds()) {
+			p.Player.Inventory[it.Kind]++
+			continue
+		}
+		kept = append(kept, it)
+	}
+	p.Items = kept
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"image/color"
 	"log"
 
@@ -8,8 +9,6 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-var PlayButtonNormal, PlayButtonNormalPushed, MenuNormalBtn, MenuPushedBtn *ebiten.Image
-
 func DrawTextAtCenter(screen *ebiten.Image, text string) {
 	bounds := screen.Bounds()
 	x := (bounds.Dx() - len(text)*7) / 2 // Approximate character width
@@ -17,24 +16,11 @@ func DrawTextAtCenter(screen *ebiten.Image, text string) {
 	ebitenutil.DebugPrintAt(screen, text, x, y)
 }
 
-func init() {
-	// Load button atlas and initialize button images and button objects
-	atlas, _, err := ebitenutil.NewImageFromFile("assets/bluebuttons.png")
-	if err != nil {
-		log.Fatal(err)
+// LoadButtons loads the button images. Without them the menu shows text buttons.
+func LoadButtons() {
+	if err := LoadButtonImages(BUTTON_ATLAS_PATH); err != nil {
+		log.Printf("warning: could not load button images: %v", err)
 	}
-
-	PlayButtonNormal = atlas.SubImage(PlayingNormalRect).(*ebiten.Image)
-	PlayButtonNormalPushed = atlas.SubImage(PlayingPushedRect).(*ebiten.Image)
-	MenuNormalBtn = atlas.SubImage(MenuNormalRect).(*ebiten.Image)
-	MenuPushedBtn = atlas.SubImage(MenuPushedRect).(*ebiten.Image)
-
-	// Place the Start and Exit buttons in the top-left of the screen
-	StartGameButton = NewCustomButton(10, 10, 64, 32, 2.0, PlayButtonNormal)
-	ExitGameButton = NewCustomButton(10, 60, 64, 32, 2.0, MenuNormalBtn)
-	StartGameButtonPushed = NewCustomButton(10, 10, 64, 32, 2.0, PlayButtonNormalPushed)
-	ExitGameButtonPushed = NewCustomButton(10, 60, 64, 32, 2.0, MenuPushedBtn)
-
 }
 
 type Game struct {
@@ -43,8 +29,19 @@ type Game struct {
 }
 
 func NewGame() *Game {
+	g := NewGameWithInput(nil, nil)
+	// players remap controls in their controls file
+	if err := g.manager.actions().Load(ControlsConfigPath()); err != nil {
+		log.Printf("warning: could not load controls: %v", err)
+	}
+	return g
+}
+
+// NewGameWithInput creates a game reading the given input and frame time instead of ebiten's,
+// so it can run headless: calling Update drives it without a window. nil keeps ebiten's.
+func NewGameWithInput(input Input, frameTime func() float64) *Game {
 	g := &Game{}
-	g.manager = &SceneManager{}
+	g.manager = &SceneManager{Input: input, FrameTime: frameTime}
 	g.manager.GoTo(NewMenuScene(g.manager))
 	return g
 }
@@ -55,9 +52,6 @@ func (g *Game) Update() error {
 
 func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{120, 180, 255, 255}) // gray background
-	if StartGameButton != nil {
-		StartGameButton.Draw(screen)
-	}
 	g.manager.Draw(screen)
 }
 
@@ -65,19 +59,38 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 	return g.manager.Layout(outsideWidth, outsideHeight)
 }
 
-var StartGameButton *CustomButton
-var ExitGameButton *CustomButton
-var StartGameButtonPushed *CustomButton
-var ExitGameButtonPushed *CustomButton
-
 func main() {
+	record := flag.String("record", "", "record the first game played to this replay file")
+	replay := flag.String("replay", "", "play back a replay file recorded with -record")
+	flag.Parse()
+
 	ebiten.SetWindowTitle("Bullet Quest 2D")
 	ebiten.SetWindowSize(1280, 720)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetTPS(60)
-	// Load character sprites used by the PlayScene
+	LoadButtons()
+	// Load character sprites, item icons and weapons used by the PlayScene
 	LoadGameCharacters()
-	if err := ebiten.RunGame(NewGame()); err != nil {
+	LoadItems()
+	LoadWeapons()
+	game := NewGame()
+	var recorder *Recorder
+	if *record != "" {
+		recorder = NewRecorder(*record)
+		game.manager.Hook = recorder
+	}
+	if *replay != "" {
+		r, err := LoadReplay(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		PlayReplay(game.manager, r)
+	}
+	err := ebiten.RunGame(game)
+	if recorder != nil {
+		recorder.Stop()
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 
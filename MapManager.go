@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// default tile size, used when a map doesn't declare one
+	TILE_SIZE = 16
+
+	// Layers with this name (or a "collision" bool property) are never drawn;
+	// every non-empty tile in them is solid.
+	COLLISION_LAYER_NAME = "collision"
+)
+
+// MapManager is what PlayScene needs from the loaded map.
+type MapManager interface {
+	Draw(screen *ebiten.Image)
+	CanMoveHere(x, y float64) bool
+	CanMoveHitbox(hb RectF) bool
+	Update(delta float64)
+
+	// layer by layer drawing for the render queue
+	LayerCount() int
+	LayerPass(index int) LayerPass
+	DrawLayer(screen *ebiten.Image, index int)
+	VisibleRows(index int) (int, int)
+	DrawLayerRow(screen *ebiten.Image, index, row int)
+	RowSortY(index, row int) float64
+}
+
+// LayerPass says when a layer is drawn relative to the entities.
+type LayerPass int
+
+const (
+	// drawn before all entities (the default)
+	LAYER_PASS_BELOW LayerPass = iota
+	// each row of tiles is sorted with the entities by its bottom edge (layer property "ysort")
+	LAYER_PASS_YSORT
+	// drawn after all entities (layer property "above")
+	LAYER_PASS_ABOVE
+)
+
+// RectF is an axis aligned rectangle in world pixels.
+type RectF struct {
+	X, Y, W, H float64
+}
+
+func (r RectF) Offset(dx, dy float64) RectF {
+	return RectF{X: r.X + dx, Y: r.Y + dy, W: r.W, H: r.H}
+}
+
+func (r RectF) Intersects(o RectF) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W && r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
+// TileMapManager owns the loaded tilemap: it draws the visible layers and
+// answers collision queries against the solid tiles.
+type TileMapManager struct {
+	tilemap *TilemapJSON
+	camera  *Camera
+
+	// area covered by the map in tiles; infinite maps can start at negative coordinates
+	bounds image.Rectangle
+	// tile size in pixels
+	tileW int
+	tileH int
+
+	// solid[(ty-bounds.Min.Y)*bounds.Dx()+(tx-bounds.Min.X)] is true when the tile blocks movement
+	solid []bool
+	// gids flagged with a "solid" (or "collides") property in their tileset
+	solidGIDs map[uint32]bool
+
+	// CacheLayers draws tile layers from pre-rendered chunk images instead of tile by tile
+	CacheLayers bool
+	// caches[i] holds the baked chunks of m.tilemap.Layers[i]
+	caches []layerCache
+
+	// time in seconds shared by all tile animations, advanced by Update
+	clock float64
+}
+
+// NewTileMapManager expects the tileset images to be loaded already (see TilemapJSON.LoadTilesetImages).
+func NewTileMapManager(tm *TilemapJSON, camera *Camera) *TileMapManager {
+	m := &TileMapManager{tilemap: tm, camera: camera, tileW: TILE_SIZE, tileH: TILE_SIZE, CacheLayers: true}
+	if tm == nil {
+		return m
+	}
+	m.tileW, m.tileH = tm.TileWidth, tm.TileHeight
+	if !tm.Infinite {
+		m.bounds = image.Rect(0, 0, tm.Width, tm.Height)
+	}
+	for _, layer := range tm.Layers {
+		if layer.Type == LAYER_TYPE_TILE {
+			m.bounds = m.bounds.Union(layer.TileBounds())
+		}
+	}
+	m.solid = make([]bool, m.bounds.Dx()*m.bounds.Dy())
+
+	m.caches = make([]layerCache, len(tm.Layers))
+
+	m.solidGIDs = make(map[uint32]bool)
+	for _, ts := range tm.Tilesets {
+		for _, tile := range ts.Tiles {
+			if tile.Properties.Bool("solid") || tile.Properties.Bool("collides") {
+				m.solidGIDs[uint32(ts.FirstGID+tile.ID)] = true
+			}
+		}
+	}
+
+	for _, layer := range tm.Layers {
+		if layer.Type != LAYER_TYPE_TILE {
+			continue
+		}
+		collisionLayer := isCollisionLayer(&layer)
+		for _, chunk := range layer.TileChunks() {
+			for index, id := range chunk.Data {
+				if id == 0 {
+					continue
+				}
+				if gid, _ := SplitGID(id); collisionLayer || m.solidGIDs[gid] {
+					tx := chunk.X + index%chunk.Width
+					ty := chunk.Y + index/chunk.Width
+					m.solid[m.solidIndex(tx, ty)] = true
+				}
+			}
+		}
+	}
+	return m
+}
+
+func isCollisionLayer(layer *TilemapLayerJSON) bool {
+	return strings.EqualFold(layer.Name, COLLISION_LAYER_NAME) || layer.Properties.Bool("collision")
+}
+
+// isDrawnLayer reports whether the layer is a visible tile layer that isn't used for collision.
+func isDrawnLayer(layer *TilemapLayerJSON) bool {
+	return layer.Type == LAYER_TYPE_TILE && layer.Visible && !isCollisionLayer(layer)
+}
+
+// SetTile replaces the raw gid at tile coordinates tx, ty of the given layer, updating
+// collision and invalidating the baked chunk holding the tile.
+func (m *TileMapManager) SetTile(layerIndex, tx, ty int, gid uint32) error {
+	if m.tilemap == nil || layerIndex < 0 || layerIndex >= len(m.tilemap.Layers) {
+		return fmt.Errorf("no layer %d", layerIndex)
+	}
+	layer := &m.tilemap.Layers[layerIndex]
+	if layer.Type != LAYER_TYPE_TILE {
+		return fmt.Errorf("layer %q is not a tile layer", layer.Name)
+	}
+	if !layer.SetTileAt(tx, ty, gid) {
+		return fmt.Errorf("layer %q has no tile at %d,%d", layer.Name, tx, ty)
+	}
+
+	if image.Pt(tx, ty).In(m.bounds) {
+		solid := false
+		for i := range m.tilemap.Layers {
+			l := &m.tilemap.Layers[i]
+			if l.Type != LAYER_TYPE_TILE {
+				continue
+			}
+			if id, _ := SplitGID(l.TileAt(tx, ty)); id != 0 && (isCollisionLayer(l) || m.solidGIDs[id]) {
+				solid = true
+				break
+			}
+		}
+		m.solid[m.solidIndex(tx, ty)] = solid
+	}
+
+	m.caches[layerIndex].invalidate(tx, ty)
+	return nil
+}
+
+func (m *TileMapManager) solidIndex(tx, ty int) int {
+	return (ty-m.bounds.Min.Y)*m.bounds.Dx() + (tx - m.bounds.Min.X)
+}
+
+// WorldBounds returns the area covered by the map in pixels. Its origin is negative
+// for infinite maps with chunks left of or above 0,0.
+func (m *TileMapManager) WorldBounds() image.Rectangle {
+	return image.Rect(m.bounds.Min.X*m.tileW, m.bounds.Min.Y*m.tileH, m.bounds.Max.X*m.tileW, m.bounds.Max.Y*m.tileH)
+}
+
+// IsSolidTile reports whether the tile at tile coordinates tx, ty blocks movement.
+// Everything outside the map is solid so characters can't leave it.
+func (m *TileMapManager) IsSolidTile(tx, ty int) bool {
+	if !image.Pt(tx, ty).In(m.bounds) {
+		return true
+	}
+	return m.solid[m.solidIndex(tx, ty)]
+}
+
+// CanMoveHere reports whether the world point x, y is walkable.
+func (m *TileMapManager) CanMoveHere(x, y float64) bool {
+	return !m.IsSolidTile(int(math.Floor(x/float64(m.tileW))), int(math.Floor(y/float64(m.tileH))))
+}
+
+// CanMoveHitbox reports whether the whole hitbox (in world pixels) is free of solid tiles.
+func (m *TileMapManager) CanMoveHitbox(hb RectF) bool {
+	if hb.W <= 0 || hb.H <= 0 {
+		return m.CanMoveHere(hb.X, hb.Y)
+	}
+	// the right/bottom edges are exclusive, so a box ending exactly on a tile border doesn't touch the next tile
+	tw, th := float64(m.tileW), float64(m.tileH)
+	minTX := int(math.Floor(hb.X / tw))
+	minTY := int(math.Floor(hb.Y / th))
+	maxTX := int(math.Ceil((hb.X+hb.W)/tw)) - 1
+	maxTY := int(math.Ceil((hb.Y+hb.H)/th)) - 1
+	for ty := minTY; ty <= maxTY; ty++ {
+		for tx := minTX; tx <= maxTX; tx++ {
+			if m.IsSolidTile(tx, ty) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Update advances the tile animations by delta seconds.
+func (m *TileMapManager) Update(delta float64) {
+	m.clock += delta
+}
+
+// visibleTiles returns the tile area seen by the camera, or the whole map without a camera.
+func (m *TileMapManager) visibleTiles() image.Rectangle {
+	if m.camera == nil {
+		return m.bounds
+	}
+	return image.Rect(
+		int(math.Floor(m.camera.X/float64(m.tileW))),
+		int(math.Floor(m.camera.Y/float64(m.tileH))),
+		int(math.Ceil((m.camera.X+float64(m.camera.ScreenW))/float64(m.tileW))),
+		int(math.Ceil((m.camera.Y+float64(m.camera.ScreenH))/float64(m.tileH))),
+	)
+}
+
+func (m *TileMapManager) Draw(screen *ebiten.Image) {
+	if m.tilemap == nil {
+		// nothing to draw
+		log.Println("tilemapJSON is nil")
+		return
+	}
+	for i := range m.tilemap.Layers {
+		m.DrawLayer(screen, i)
+	}
+}
+
+func (m *TileMapManager) LayerCount() int {
+	if m.tilemap == nil {
+		return 0
+	}
+	return len(m.tilemap.Layers)
+}
+
+func (m *TileMapManager) LayerPass(index int) LayerPass {
+	props := m.tilemap.Layers[index].Properties
+	switch {
+	case props.Bool("above"):
+		return LAYER_PASS_ABOVE
+	case props.Bool("ysort"):
+		return LAYER_PASS_YSORT
+	}
+	return LAYER_PASS_BELOW
+}
+
+// layerVisibleTiles returns the layer's tiles seen by the camera, grown by one tile so
+// offset layers and tall tiles don't pop at the edges.
+func (m *TileMapManager) layerVisibleTiles(layer *TilemapLayerJSON) image.Rectangle {
+	return m.visibleTiles().Add(image.Pt(
+		-int(math.Floor(layer.OffsetX/float64(m.tileW))),
+		-int(math.Floor(layer.OffsetY/float64(m.tileH))),
+	)).Inset(-1)
+}
+
+// VisibleRows returns the range [min, max) of the layer's tile rows seen by the camera.
+func (m *TileMapManager) VisibleRows(index int) (int, int) {
+	layer := &m.tilemap.Layers[index]
+	area := m.layerVisibleTiles(layer).Intersect(layer.TileBounds())
+	return area.Min.Y, area.Max.Y
+}
+
+// RowSortY returns the world y of the bottom edge of a layer's tile row.
+func (m *TileMapManager) RowSortY(index, row int) float64 {
+	return float64((row+1)*m.tileH) + m.tilemap.Layers[index].OffsetY
+}
+
+// DrawLayerRow draws the visible tiles of one row of a layer, for y-sorted layers.
+func (m *TileMapManager) DrawLayerRow(screen *ebiten.Image, index, row int) {
+	layer := &m.tilemap.Layers[index]
+	if !isDrawnLayer(layer) {
+		return
+	}
+	visible := m.layerVisibleTiles(layer)
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+	originX, originY := m.layerOrigin(layer)
+	for tx := visible.Min.X; tx < visible.Max.X; tx++ {
+		m.drawTile(screen, layer.TileAt(tx, row), tx, row, originX, originY, opts)
+	}
+}
+
+// DrawLayer draws a single layer of the map. Layers that aren't drawn tile layers are skipped.
+func (m *TileMapManager) DrawLayer(screen *ebiten.Image, index int) {
+	layer := &m.tilemap.Layers[index]
+	if !isDrawnLayer(layer) {
+		return
+	}
+
+	visible := m.layerVisibleTiles(layer)
+
+	if m.CacheLayers {
+		m.drawCachedLayer(screen, index, visible)
+		return
+	}
+
+	// reuse options to avoid allocating per-tile
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+	originX, originY := m.layerOrigin(layer)
+
+	chunks := layer.TileChunks()
+	for ci := range chunks {
+		chunk := &chunks[ci]
+		// only visit chunks intersecting the viewport
+		area := chunk.Bounds().Intersect(visible)
+		if area.Empty() {
+			continue
+		}
+		for ty := area.Min.Y; ty < area.Max.Y; ty++ {
+			for tx := area.Min.X; tx < area.Max.X; tx++ {
+				m.drawTile(screen, chunk.At(tx, ty), tx, ty, originX, originY, opts)
+			}
+		}
+	}
+}
+
+// layerOrigin returns where the layer's tile 0,0 is drawn on screen.
+func (m *TileMapManager) layerOrigin(layer *TilemapLayerJSON) (float64, float64) {
+	x, y := layer.OffsetX, layer.OffsetY
+	if m.camera != nil {
+		x -= m.camera.X
+		y -= m.camera.Y
+	}
+	return x, y
+}
+
+// drawTile draws the raw gid of tile tx, ty with the tile 0,0 placed at originX, originY.
+// opts.ColorScale is left as is; opts.GeoM is overwritten.
+func (m *TileMapManager) drawTile(dst *ebiten.Image, raw uint32, tx, ty int, originX, originY float64, opts *ebiten.DrawImageOptions) {
+	gid, flip := SplitGID(raw)
+	// skip empty tiles (commonly 0 in Tiled exports)
+	if gid == 0 {
+		return
+	}
+	ts, localID := m.tilemap.TilesetForGID(gid)
+	if ts == nil || ts.Img == nil {
+		return
+	}
+
+	localID = ts.AnimatedTile(localID, int64(m.clock*1000))
+
+	// a diagonal flip swaps the drawn width and height
+	drawH := ts.TileHeight
+	if flip.Diagonal() {
+		drawH = ts.TileWidth
+	}
+
+	// convert the tile position to pixel position; tiles taller than the grid grow upwards like in Tiled
+	px := float64(tx*m.tileW) + originX
+	py := float64((ty+1)*m.tileH-drawH) + originY
+
+	// set the draw options and draw
+	opts.GeoM = flip.GeoM(float64(ts.TileWidth), float64(ts.TileHeight))
+	opts.GeoM.Translate(px, py)
+	dst.DrawImage(ts.Img.SubImage(ts.SourceRect(localID)).(*ebiten.Image), opts)
+}
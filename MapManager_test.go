@@ -0,0 +1,208 @@
+package main
+
+import (
+	"image"
+	"maps"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// syntheticTilemap builds a width x height single layer map using every tile of a floorsheet sized tileset.
+func syntheticTilemap(width, height int) *TilemapJSON {
+	data := make([]uint32, width*height)
+	for i := range data {
+		data[i] = uint32(1 + i%(22*26))
+	}
+	return &TilemapJSON{
+		Width:      width,
+		Height:     height,
+		TileWidth:  TILE_SIZE,
+		TileHeight: TILE_SIZE,
+		Layers: []TilemapLayerJSON{{
+			Name:    "ground",
+			Type:    LAYER_TYPE_TILE,
+			Visible: true,
+			Opacity: 1,
+			Width:   width,
+			Height:  height,
+			Data:    data,
+		}},
+		Tilesets: []TilesetJSON{{
+			FirstGID:   1,
+			TileWidth:  TILE_SIZE,
+			TileHeight: TILE_SIZE,
+			Columns:    22,
+			Img:        ebiten.NewImage(352, 417),
+		}},
+	}
+}
+
+func loadDirtmap(b *testing.B) *TilemapJSON {
+	tm, err := NewTilemapJSON("assets/maps/dirtmap.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	tm.LoadTilesetImages(ebiten.NewImage(352, 417))
+	return tm
+}
+
+// benchmarkMapDraw draws the map into a screen sized image. Without a camera every tile of the
+// map is drawn one by one, which is what PlayScene used to do each frame.
+func benchmarkMapDraw(b *testing.B, tm *TilemapJSON, withCamera, cached bool) {
+	var cam *Camera
+	if withCamera {
+		cam = NewCamera(GAME_WIDTH, GAME_HEIGHT, 0, 0)
+	}
+	m := NewTileMapManager(tm, cam)
+	m.CacheLayers = cached
+	if cam != nil {
+		// look at the middle of the map
+		world := m.WorldBounds()
+		cam.SetWorldBounds(world)
+		cam.X = float64(world.Min.X+world.Max.X-GAME_WIDTH) / 2
+		cam.Y = float64(world.Min.Y+world.Max.Y-GAME_HEIGHT) / 2
+	}
+	screen := ebiten.NewImage(GAME_WIDTH, GAME_HEIGHT)
+
+	// bake the visible chunks before measuring
+	m.Draw(screen)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Draw(screen)
+	}
+}
+
+func BenchmarkDrawDirtmapAllTiles(b *testing.B) { benchmarkMapDraw(b, loadDirtmap(b), false, false) }
+func BenchmarkDrawDirtmapCulled(b *testing.B)   { benchmarkMapDraw(b, loadDirtmap(b), true, false) }
+func BenchmarkDrawDirtmapCached(b *testing.B)   { benchmarkMapDraw(b, loadDirtmap(b), true, true) }
+
+func BenchmarkDrawSynthetic512AllTiles(b *testing.B) {
+	benchmarkMapDraw(b, syntheticTilemap(512, 512), false, false)
+}
+func BenchmarkDrawSynthetic512Culled(b *testing.B) {
+	benchmarkMapDraw(b, syntheticTilemap(512, 512), true, false)
+}
+func BenchmarkDrawSynthetic512Cached(b *testing.B) {
+	benchmarkMapDraw(b, syntheticTilemap(512, 512), true, true)
+}
+
+func TestSetTileInvalidatesCache(t *testing.T) {
+	tm := syntheticTilemap(40, 40)
+	tm.Tilesets[0].Tiles = []TilesetTileJSON{{ID: 4, Properties: TiledProperties{{Name: "solid", Type: "bool", Value: true}}}}
+	m := NewTileMapManager(tm, nil)
+	m.Draw(ebiten.NewImage(GAME_WIDTH, GAME_HEIGHT))
+
+	key := cacheKey(20, 17)
+	if cc := m.caches[0].chunks[key]; cc == nil || cc.dirty {
+		t.Fatalf("chunk %v should be baked after drawing", key)
+	}
+	if m.IsSolidTile(20, 17) {
+		t.Fatal("tile 20,17 should not be solid yet")
+	}
+
+	if err := m.SetTile(0, 20, 17, 5|FLIPPED_HORIZONTALLY_FLAG); err != nil {
+		t.Fatal(err)
+	}
+	if !m.caches[0].chunks[key].dirty {
+		t.Errorf("chunk %v should be dirty after SetTile", key)
+	}
+	if other := m.caches[0].chunks[cacheKey(0, 0)]; other.dirty {
+		t.Error("chunk 0,0 should not be invalidated")
+	}
+	if !m.IsSolidTile(20, 17) {
+		t.Error("tile 20,17 should be solid after placing a solid tile")
+	}
+	if err := m.SetTile(0, 40, 0, 1); err == nil {
+		t.Error("SetTile outside the layer should fail")
+	}
+}
+
+func TestShippedMapCollision(t *testing.T) {
+	tm, err := NewTilemapJSON(DEFAULT_MAP_PATH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ts := range tm.Tilesets {
+		if ts.Source != "" || ts.Image == "" {
+			t.Errorf("tileset %q should be embedded with its image", ts.Name)
+			continue
+		}
+		if _, err := os.Stat(resolvePath(ts.dir, ts.Image)); err != nil {
+			t.Errorf("tileset %q: %v", ts.Name, err)
+		}
+	}
+
+	m := NewTileMapManager(tm, nil)
+	// the wall runs down column 26; the dirt patch at 2..8, 2..10 is walkable
+	tile := func(tx, ty int) RectF {
+		return RectF{X: float64(tx*TILE_SIZE) + 3, Y: float64(ty*TILE_SIZE) + 4, W: 10, H: 12}
+	}
+	if m.CanMoveHitbox(tile(26, 5)) || m.CanMoveHitbox(tile(26, 30)) {
+		t.Error("the wall doesn't block")
+	}
+	if !m.CanMoveHitbox(tile(10, 4)) || !m.CanMoveHitbox(tile(4, 5)) || !m.CanMoveHitbox(tile(28, 5)) {
+		t.Error("open ground blocks")
+	}
+
+	// nothing spawns stuck in a wall; the map spawns the enemies of the character file
+	defs := maps.Clone(CharacterDefs)
+	t.Cleanup(func() { CharacterDefs = defs })
+	if err := LoadCharacterDefs(CHARACTERS_PATH); err != nil {
+		t.Fatal(err)
+	}
+	p := NewPlaySceneWithTilemap(nil, tm, DEFAULT_MAP_PATH)
+	if len(p.Enemies) == 0 {
+		t.Fatal("no enemies spawned from the map")
+	}
+	for _, e := range p.Enemies {
+		if !m.CanMoveHitbox(e.HitboxAt(e.Position.X, e.Position.Y)) {
+			t.Errorf("enemy spawned in a wall at %v", e.Position)
+		}
+	}
+	if !p.canMoveTo(p.Player.Character, p.Player.Position.X, p.Player.Position.Y) {
+		t.Errorf("the player spawned in a wall at %v", p.Player.Position)
+	}
+}
+
+func TestCacheKeyNegative(t *testing.T) {
+	tests := []struct {
+		tx, ty int
+		want   image.Point
+	}{
+		{0, 0, image.Pt(0, 0)},
+		{15, 16, image.Pt(0, 1)},
+		{-1, -1, image.Pt(-1, -1)},
+		{-16, -17, image.Pt(-1, -2)},
+		{-33, 31, image.Pt(-3, 1)},
+	}
+	for _, tt := range tests {
+		if got := cacheKey(tt.tx, tt.ty); got != tt.want {
+			t.Errorf("cacheKey(%d, %d) = %v, want %v", tt.tx, tt.ty, got, tt.want)
+		}
+	}
+	if floorDiv(-1, 16) != -1 || floorDiv(-16, 16) != -1 || floorDiv(5, -2) != -3 || floorDiv(-4, -2) != 2 {
+		t.Error("floorDiv should round down")
+	}
+
+	tm := syntheticTilemap(1, 1)
+	tm.Infinite = true
+	tm.Layers[0] = chunkedLayer()
+	m := NewTileMapManager(tm, nil)
+	m.Draw(ebiten.NewImage(GAME_WIDTH, GAME_HEIGHT))
+	if len(m.caches[0].chunks) != 2 {
+		t.Fatalf("%d chunks baked, want the two either side of x = 0", len(m.caches[0].chunks))
+	}
+	for _, key := range []image.Point{{-1, -1}, {0, -1}} {
+		if cc := m.caches[0].chunks[key]; cc == nil || cc.dirty || cc.img == nil {
+			t.Fatalf("chunk %v should be baked after drawing", key)
+		}
+	}
+	if err := m.SetTile(0, -1, -1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !m.caches[0].chunks[image.Pt(-1, -1)].dirty || m.caches[0].chunks[image.Pt(0, -1)].dirty {
+		t.Error("setting tile -1,-1 should invalidate only chunk -1,-1")
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// width of the entries of the overlay menus
+const OVERLAY_MENU_WIDTH = 120
+
+// overlayMenu lays out a title over a column of entries of the same width, in the middle of the screen.
+// status, when not nil, is a line of feedback in the bottom-right corner, clear of the column.
+func overlayMenu(title string, status ui.Widget, entries ...ui.Widget) *ui.UI {
+	heading := ui.NewLabel(title)
+	heading.Center = true
+	items := append([]ui.Widget{heading, ui.NewSpacer(OVERLAY_MENU_WIDTH, 0)}, entries...)
+	column := ui.NewVBox(1, items...)
+	column.Stretch = true
+	anchor := ui.NewAnchor(ui.Anchored{Widget: column, At: ui.ANCHOR_CENTER})
+	if status != nil {
+		anchor.Items = append(anchor.Items, ui.Anchored{Widget: status, At: ui.ANCHOR_BOTTOM_RIGHT, Offset: image.Pt(-4, -2)})
+	}
+	u := ui.New(anchor)
+	u.Layout(image.Rect(0, 0, 320, 128))
+	return u
+}
+
+// dimScreen darkens what's drawn beneath an overlay.
+func dimScreen(screen *ebiten.Image) {
+	b := screen.Bounds()
+	vector.FillRect(screen, float32(b.Min.X), float32(b.Min.Y), float32(b.Dx()), float32(b.Dy()), color.RGBA{0, 0, 0, 0x90}, false)
+}
+
+// PauseScene is pushed over a PlayScene, which stays visible dimmed beneath it with its clock
+// stopped. Cancel or Pause resume the game.
+type PauseScene struct {
+	sm   *SceneManager
+	play *PlayScene
+	ui   *ui.UI
+	// ResumeButton, SettingsButton, SaveButton and QuitButton are the entries from top to bottom
+	ResumeButton   *ui.Button
+	SettingsButton *ui.Button
+	SaveButton     *ui.Button
+	QuitButton     *ui.Button
+	// message is shown in the corner, e.g. once the game was saved
+	message *ui.Label
+}
+
+func NewPauseScene(sm *SceneManager, play *PlayScene) *PauseScene {
+	s := &PauseScene{sm: sm, play: play}
+	s.ResumeButton = ui.NewButton("Resume", s.resume)
+	s.SettingsButton = ui.NewButton("Settings", func() { sm.Push(NewSettingsScene(sm)) })
+	s.SaveButton = ui.NewButton("Save", s.save)
+	s.QuitButton = ui.NewButton("Quit to Menu", func() {
+		sm.GoToWith(NewMenuScene(sm), Transition{Kind: TRANSITION_FADE, Duration: TRANSITION_FADE_TIME})
+	})
+	s.message = ui.NewLabel("")
+	s.ui = overlayMenu("Paused", s.message, s.ResumeButton, s.SettingsButton, s.SaveButton, s.QuitButton)
+	return s
+}
+
+func (s *PauseScene) Enter()            {}
+func (s *PauseScene) Exit()             {}
+func (s *PauseScene) Pause()            {}
+func (s *PauseScene) Resume()           {}
+func (s *PauseScene) DrawBelow() bool   { return true }
+func (s *PauseScene) UpdateBelow() bool { return false }
+
+func (s *PauseScene) resume() {
+	if s.sm.Current() == Scene(s) {
+		s.sm.Pop()
+	}
+}
+
+func (s *PauseScene) save() {
+	if err := s.play.SaveGame().Save(s.sm.savePath()); err != nil {
+		log.Printf("warning: could not save the game: %v", err)
+		s.setMessage("Could not save")
+		return
+	}
+	s.setMessage("Saved")
+}
+
+// setMessage shows text in the corner; the label is laid out again as its size changes.
+func (s *PauseScene) setMessage(text string) {
+	s.message.Text = text
+	s.ui.Layout(image.Rect(0, 0, 320, 128))
+}
+
+func (s *PauseScene) Update() error {
+	actions := s.sm.actions()
+	if actions.JustPressed(ActionCancel) || actions.JustPressed(ActionPause) {
+		s.resume()
+		return nil
+	}
+	s.ui.Update(s.sm.uiInput())
+	return nil
+}
+
+func (s *PauseScene) Draw(screen *ebiten.Image) {
+	dimScreen(screen)
+	// pages pushed over the pause menu, like the settings, draw in its place
+	if s.sm.Current() == Scene(s) {
+		s.ui.Draw(screen)
+	}
+}
+
+func (s *PauseScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return 320, 128
+}
+
+// SettingsScene is pushed over the pause menu and shown in its place.
+type SettingsScene struct {
+	sm *SceneManager
+	ui *ui.UI
+}
+
+func NewSettingsScene(sm *SceneManager) *SettingsScene {
+	s := &SettingsScene{sm: sm}
+	s.ui = overlayMenu("Settings", nil,
+		ui.NewCheckbox("Fullscreen", ebiten.IsFullscreen(), ebiten.SetFullscreen),
+		ui.NewButton("Reset controls", s.resetControls),
+		ui.NewButton("Back", s.back),
+	)
+	return s
+}
+
+// resetControls restores the default bindings and saves them to the controls file.
+func (s *SettingsScene) resetControls() {
+	s.sm.actions().ResetAll()
+	if err := s.sm.actions().Save(ControlsConfigPath()); err != nil {
+		log.Printf("warning: could not save controls: %v", err)
+	}
+}
+
+func (s *SettingsScene) back() {
+	if s.sm.Current() == Scene(s) {
+		s.sm.Pop()
+	}
+}
+
+func (s *SettingsScene) Enter()            {}
+func (s *SettingsScene) Exit()             {}
+func (s *SettingsScene) Pause()            {}
+func (s *SettingsScene) Resume()           {}
+func (s *SettingsScene) DrawBelow() bool   { return true }
+func (s *SettingsScene) UpdateBelow() bool { return false }
+
+func (s *SettingsScene) Update() error {
+	if s.sm.actions().JustPressed(ActionCancel) {
+		s.back()
+		return nil
+	}
+	s.ui.Update(s.sm.uiInput())
+	return nil
+}
+
+func (s *SettingsScene) Draw(screen *ebiten.Image) {
+	s.ui.Draw(screen)
+}
+
+func (s *SettingsScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return 320, 128
+}
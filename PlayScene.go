@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"image"
 	"log"
-	"math"
+	"math/rand"
+	"time"
 
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
@@ -13,247 +16,360 @@ import (
 type PlayScene struct {
 	sm         *SceneManager
 	Player     *Player
-	MapManager interface {
-		Draw(screen *ebiten.Image)
-		CanMoveHere(x, y float64) bool
-	}
-	Skeleton    *Character
-	PlayingUI   interface{ DrawUI(screen *ebiten.Image) }
-	BtnExit     *CustomButton
+	MapManager MapManager
+	// Enemies, Items and Triggers are spawned from the map's object layers
+	Enemies  []*Enemy
+	Items    []*Item
+	Triggers []*Trigger
+	// MenuButton in the top-right corner pauses the game like Pause does
+	MenuButton *ui.Button
+	hud        *ui.UI
+	// hudPressed is set while the mouse button is held over the HUD, whose clicks don't shoot
+	hudPressed  bool
 	tilemapJSON *TilemapJSON
-	tilemapImg  *ebiten.Image
 	Camera      *Camera
+	mapPath     string
+	renderQueue RenderQueue
+	// rng drives enemy decisions; seed is what it started from
+	rng         *rand.Rand
+	seed        int64
+	projectiles *ProjectilePool
+	// seconds left before switching to the game over scene once the player died, 0 while alive
+	gameOverIn float64
+	// Clock runs the simulation in fixed steps; its TimeScale gives slow motion and pause
+	Clock *SimClock
+	// exited stops the remaining steps of a frame once the scene was left
+	exited bool
+	// clockPaused is the clock's own pause while a scene over this one stops it
+	clockPaused bool
 }
 
+// seconds the death of the player stays on screen before the game over scene
+const GAME_OVER_DELAY = 1.0
+
+// map loaded by NewPlayScene
+const DEFAULT_MAP_PATH = "assets/maps/dirtmap.json"
+
 func NewPlayScene(sm *SceneManager) *PlayScene {
-	p := &PlayScene{sm: sm, Player: NewPlayer()}
+	return NewPlaySceneWithMap(sm, DEFAULT_MAP_PATH)
+}
 
-	// attempt to load the tilemap JSON and tileset image for the PlayScene
-	if tm, err := NewTilemapJSON("assets/maps/dirtmap.json"); err != nil {
-		log.Println("failed to load tilemap JSON:", err)
+// NewPlaySceneWithMap loads the given Tiled map (finite or infinite) instead of the default one.
+func NewPlaySceneWithMap(sm *SceneManager, mapPath string) *PlayScene {
+	// attempt to load the tilemap JSON and tileset images for the PlayScene.
+	// floorsheet.png is the fallback for tilesets whose image isn't shipped with the map.
+	var tilemapImg *ebiten.Image
+	if img, _, err := ebitenutil.NewImageFromFile("assets/maps/floorsheet.png"); err != nil {
+		log.Println("failed to load tilemap image:", err)
 	} else {
-		p.tilemapJSON = tm
+		tilemapImg = img
 	}
 
-	if img, _, err := ebitenutil.NewImageFromFile("assets/maps/floorsheet.png"); err != nil {
-		log.Println("failed to load tilemap image:", err)
+	tm, err := NewTilemapJSON(mapPath)
+	if err != nil {
+		log.Println("failed to load tilemap JSON:", err)
+		tm = nil
 	} else {
-		p.tilemapImg = img
+		tm.LoadTilesetImages(tilemapImg)
+	}
+	p := NewPlaySceneWithTilemap(sm, tm, mapPath)
+	return p
+}
+
+// NewPlaySceneWithTilemap builds the scene around an already loaded map, which may be nil.
+// mapPath is where the map came from, used to restart it and to resolve the maps it links to.
+func NewPlaySceneWithTilemap(sm *SceneManager, tm *TilemapJSON, mapPath string) *PlayScene {
+	seed := time.Now().UnixNano()
+	if sm != nil && sm.Seed != 0 {
+		seed = sm.Seed
+	}
+	p := &PlayScene{
+		sm:          sm,
+		Player:      NewPlayer(),
+		mapPath:     mapPath,
+		tilemapJSON: tm,
+		rng:         rand.New(rand.NewSource(seed)),
+		seed:        seed,
+		projectiles: NewProjectilePool(PROJECTILE_POOL_SIZE),
+		Clock:       NewSimClock(DEFAULT_SIM_TPS),
+	}
+	if sm != nil && sm.FrameTime != nil {
+		p.Clock.FrameTime = sm.FrameTime
 	}
 
 	// Initialize camera to follow the player (not any other character).
 	// Screen size matches this scene's Layout(). World size is derived from the tilemap when available.
 	screenW, screenH := 320, 128
-	worldW, worldH := screenW, screenH
+	p.Camera = NewCamera(screenW, screenH, screenW, screenH)
 	if p.tilemapJSON != nil {
-		maxW, maxH := 0, 0
-		for _, layer := range p.tilemapJSON.Layers {
-			if layer.Width > maxW {
-				maxW = layer.Width
-			}
-			if layer.Height > maxH {
-				maxH = layer.Height
-			}
-		}
-		if maxW > 0 {
-			worldW = maxW * 16
+		mm := NewTileMapManager(p.tilemapJSON, p.Camera)
+		world := mm.WorldBounds()
+		if !world.Empty() {
+			p.Camera.SetWorldBounds(world)
 		}
-		if maxH > 0 {
-			worldH = maxH * 16
+		p.MapManager = mm
+
+		// place the player and other entities from the map's object layers. Without a player
+		// spawn the default position may be outside maps that don't start at 0,0; fall back to the world's center
+		playerPlaced := p.spawnObjects()
+		if !playerPlaced && !world.Empty() && !p.canMoveTo(p.Player.Character, p.Player.Position.X, p.Player.Position.Y) {
+			p.Player.Position.X = float64(world.Min.X+world.Max.X)/2 - SPRITE_DEFAULT_SIZE/2
+			p.Player.Position.Y = float64(world.Min.Y+world.Max.Y)/2 - SPRITE_DEFAULT_SIZE/2
 		}
+		p.updateTriggers(false)
 	}
-	p.Camera = NewCamera(screenW, screenH, worldW, worldH)
+	p.Player.Health.OnDeath = func() {
+		p.gameOverIn = GAME_OVER_DELAY
+		p.Player.Anim.PlayOnce(ANIM_DIE, nil)
+	}
+
+	p.MenuButton = PlayingMenu.NewButton("Menu", 1, p.pause)
+	p.hud = ui.New(ui.NewAnchor(ui.Anchored{Widget: p.MenuButton, At: ui.ANCHOR_TOP_RIGHT, Offset: image.Pt(-4, 4)}))
+	p.hud.Layout(image.Rect(0, 0, screenW, screenH))
 	return p
 }
 
+// FocusPoint is the center of the player on screen, which iris transitions close around.
+func (p *PlayScene) FocusPoint() (float64, float64) {
+	hb := p.Player.HitboxAt(p.Player.Position.X, p.Player.Position.Y)
+	x, y := hb.X+hb.W/2, hb.Y+hb.H/2
+	if p.Camera != nil {
+		return p.Camera.WorldToScreen(x, y)
+	}
+	return x, y
+}
+
+// Seed returns the seed of the scene's random numbers.
+func (p *PlayScene) Seed() int64 {
+	return p.seed
+}
+
 func (p *PlayScene) Enter() {}
-func (p *PlayScene) Exit()  {}
+func (p *PlayScene) Exit()  { p.exited = true }
+
+// Pause stops the game time while a scene, like the pause menu, is over this one.
+func (p *PlayScene) Pause() {
+	p.clockPaused = p.Clock.Paused
+	p.Clock.Paused = true
+}
+
+func (p *PlayScene) Resume() {
+	p.Clock.Paused = p.clockPaused
+}
+
+// pause pushes the pause menu over the scene while the player is alive.
+func (p *PlayScene) pause() {
+	if !p.Player.Health.IsDead() && p.sm.Current() == Scene(p) {
+		p.sm.Push(NewPauseScene(p.sm, p))
+	}
+}
 
 func (p *PlayScene) Update() error {
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		p.sm.GoTo(NewMenuScene(p.sm))
+	if p.sm.actions().JustPressed(ActionPause) && !p.Player.Health.IsDead() {
+		p.pause()
+		return nil
+	}
+	// the HUD is only clicked; the navigation actions move the player
+	in := p.sm.uiInput()
+	p.hud.Update(ui.Input{CursorX: in.CursorX, CursorY: in.CursorY, MouseDown: in.MouseDown})
+	p.hudPressed = in.MouseDown && p.hud.Hovered() != nil
+	if p.sm.Current() != Scene(p) {
+		return nil
+	}
+
+	// the world holds still while a transition into the scene plays, so nothing happens unseen
+	if !p.sm.Transitioning() {
+		p.Clock.Tick(p.step)
 	}
 
 	if p.Camera != nil && p.Player != nil {
 		p.Camera.FollowCharacter(p.Player.Character)
 		p.Camera.Update()
 	}
-
-	// simple fixed delta (approx 60 FPS). Replace with real delta if available.
-	delta := 1.0 / 60.0
-	p.updatePlayerMove(delta)
 	return nil
 
 }
 
-// updatePlayerMove moves the player using WASD and sets facing direction; F sets attacking flag.
+// step runs one fixed simulation step of delta seconds.
+func (p *PlayScene) step(delta float64) {
+	if p.exited {
+		return
+	}
+	if p.MapManager != nil {
+		p.MapManager.Update(delta)
+	}
+	if p.Player.Health.IsDead() {
+		// the world keeps running while the death shows, without the player
+		p.updateEnemies(delta)
+		p.updateProjectiles(delta)
+		p.animateCharacters(delta)
+		p.gameOverIn -= delta
+		if p.gameOverIn <= 0 {
+			p.sm.GoToWith(NewGameOverScene(p.sm, p.mapPath), Transition{Kind: TRANSITION_IRIS, Duration: TRANSITION_IRIS_TIME})
+		}
+		return
+	}
+	p.updateHealth(p.Player.Character, delta)
+	p.updatePlayerMove(delta)
+	p.updatePlayerAttack(delta)
+	p.updatePlayerShoot(delta)
+	p.updateEnemies(delta)
+	p.updateProjectiles(delta)
+	p.updateItems()
+	p.updateTriggers(true)
+	p.animateCharacters(delta)
+}
+
+// updatePlayerMove moves the player with the Move actions, at a speed proportional to how far a
+// stick is pushed, and sets facing direction; the Attack and Shoot actions set the attacking and shooting flags.
 func (p *PlayScene) updatePlayerMove(delta float64) {
 	if p.Player == nil {
 		return
 	}
 
 	// read input
-	up := ebiten.IsKeyPressed(ebiten.KeyW)
-	down := ebiten.IsKeyPressed(ebiten.KeyS)
-	left := ebiten.IsKeyPressed(ebiten.KeyA)
-	right := ebiten.IsKeyPressed(ebiten.KeyD)
-	attack := ebiten.IsKeyPressed(ebiten.KeyF)
-
-	p.Player.Attacking = attack
-
-	dx := 0.0
-	dy := 0.0
-	if right && !left {
-		dx = 1
-	} else if left && !right {
-		dx = -1
-	}
-	if down && !up {
-		dy = 1
-	} else if up && !down {
-		dy = -1
-	}
+	actions := p.sm.actions()
+	p.Player.Attacking = actions.Pressed(ActionAttack)
+	p.Player.Shooting = actions.Pressed(ActionShoot) && !p.hudPressed
 
-	// if no movement keys pressed, reset animation and return
+	// dx, dy has a length of 1 with keys and is analog with a stick
+	dx, dy := actions.MoveVector()
 	if dx == 0 && dy == 0 {
-		p.Player.ResetAnimation()
+		p.Player.Play(ANIM_IDLE)
 		return
 	}
 
-	// compute normalized movement similar to original algorithm
-	// baseSpeed = delta * 300
-	baseSpeed := delta * 150
+	// face the larger component of the movement
+	p.Player.SetFaceDir(faceDirTowards(dx, dy, p.Player.GetFaceDir()))
 
-	// avoid divide by zero
-	ratio := 0.0
-	if dx != 0 {
-		ratio = math.Abs(dy) / math.Abs(dx)
+	speed := delta * p.Player.MoveSpeed(PLAYER_SPEED)
+	if p.moveCharacter(p.Player.Character, dx*speed, dy*speed) {
+		p.Player.Play(ANIM_WALK)
 	} else {
-		ratio = 1e6
-	}
-	angle := math.Atan(ratio)
-	xSpeed := math.Cos(angle)
-	ySpeed := math.Sin(angle)
-
-	// determine facing based on larger component
-	if xSpeed > ySpeed {
-		if dx > 0 {
-			p.Player.SetFaceDir(FACE_DIR_RIGHT)
-		} else {
-			p.Player.SetFaceDir(FACE_DIR_LEFT)
-		}
-	} else {
-		if dy > 0 {
-			p.Player.SetFaceDir(FACE_DIR_DOWN)
-		} else {
-			p.Player.SetFaceDir(FACE_DIR_UP)
-		}
+		p.Player.Play(ANIM_IDLE)
 	}
+}
 
-	if dx < 0 {
-		xSpeed *= -1
+// animateCharacters advances the animation of every character by delta seconds.
+func (p *PlayScene) animateCharacters(delta float64) {
+	if p.Player != nil {
+		p.Player.Anim.Update(delta)
 	}
-	if dy < 0 {
-		ySpeed *= -1
+	for _, e := range p.Enemies {
+		e.Anim.Update(delta)
 	}
+}
 
-	deltaX := xSpeed * baseSpeed
-	deltaY := ySpeed * baseSpeed
-
+// moveCharacter moves c by deltaX, deltaY if the map allows it. When the full move is blocked it
+// slides along walls on a single axis. It returns whether the character moved at all.
+func (p *PlayScene) moveCharacter(c *Character, deltaX, deltaY float64) bool {
 	// proposed new position
-	newX := p.Player.Position.X + deltaX
-	newY := p.Player.Position.Y + deltaY
-
-	// ask map if movement allowed. If no MapManager provided, allow movement.
-	canMove := true
-	if p.MapManager != nil {
-		canMove = p.MapManager.CanMoveHere(newX, newY)
+	oldX, oldY := c.Position.X, c.Position.Y
+	newX := oldX + deltaX
+	newY := oldY + deltaY
+
+	// try the full move first, then slide along walls on a single axis
+	switch {
+	case p.canMoveTo(c, newX, newY):
+	case deltaX != 0 && p.canMoveTo(c, newX, oldY):
+		newY = oldY
+	case deltaY != 0 && p.canMoveTo(c, oldX, newY):
+		newX = oldX
+	default:
+		return false
 	}
 
-	if canMove {
-		p.Player.Position.X = newX
-		p.Player.Position.Y = newY
-		p.Player.UpdateAnimation()
-	} else {
-		p.Player.ResetAnimation()
+	c.Position.X = newX
+	c.Position.Y = newY
+	return true
+}
+
+// canMoveTo asks the map whether the character's hitbox fits at x, y. If no MapManager is provided, allow movement.
+func (p *PlayScene) canMoveTo(c *Character, x, y float64) bool {
+	if p.MapManager == nil {
+		return true
 	}
+	return p.MapManager.CanMoveHitbox(c.HitboxAt(x, y))
 }
 
 func (p *PlayScene) Draw(screen *ebiten.Image) {
 	screen.Clear()
 	// Render order similar to original Java: map, player, other chars, UI, buttons
-
-	// Draw exit button if provided
-	if p.BtnExit != nil {
-		p.BtnExit.Draw(screen)
-	}
-
-	if p.tilemapJSON == nil || p.tilemapImg == nil {
+	if p.MapManager == nil {
 		// nothing to draw
-		log.Println("tilemapJSON or tilemapImg is nil")
+		log.Println("tilemapJSON is nil")
 		return
 	}
+	p.drawWorld(screen)
+	p.drawHealth(screen)
 
-	// determine tiles-per-row from the spritesheet size rather than hardcoding 22
-	imgW, _ := p.tilemapImg.Size()
-	tilesPerRow := imgW / 16
-
-	// reuse options to avoid allocating per-tile
-	opts := &ebiten.DrawImageOptions{}
-
-	for _, layer := range p.tilemapJSON.Layers {
-		// loop over the tiles in the layer data
-		for index, id := range layer.Data {
-			// skip empty tiles (commonly 0 in Tiled exports)
-			if id <= 0 {
-				continue
-			}
-
-			// get the tile position of the tile (in tiles)
-			tx := index % layer.Width
-			ty := index / layer.Width
-
-			// convert the tile position to pixel position
-			px := tx * 16
-			py := ty * 16
-
-			// compute the source tile position in the spritesheet
-			sCol := (id - 1) % tilesPerRow
-			sRow := (id - 1) / tilesPerRow
-			srcX := sCol * 16
-			srcY := sRow * 16
+	// Centered help text
+	DrawTextAtCenter(screen, "Gameplay - press ESC to pause")
+	p.hud.Draw(screen)
+}
 
-			// set the draw options and draw
-			opts.GeoM.Reset()
-			opts.GeoM.Translate(float64(px), float64(py))
+// drawWorld draws the map layers and the entities. Layers marked "above" cover the entities,
+// and the rows of "ysort" layers are sorted together with the entities by their bottom edge,
+// so characters can walk behind trees and wall tops.
+func (p *PlayScene) drawWorld(screen *ebiten.Image) {
+	p.queueWorld()
+	p.renderQueue.Flush(screen)
+}
 
-			opts.GeoM.Translate(-p.Camera.X, -p.Camera.Y)
-			screen.DrawImage(p.tilemapImg.SubImage(image.Rect(srcX, srcY, srcX+16, srcY+16)).(*ebiten.Image), opts)
+// queueWorld queues everything drawWorld draws, in the render queue's order.
+func (p *PlayScene) queueWorld() {
+	mm := p.MapManager
+	for i := 0; i < mm.LayerCount(); i++ {
+		if mm.LayerPass(i) == LAYER_PASS_BELOW {
+			p.renderQueue.Add(RENDER_Y_BELOW, func(screen *ebiten.Image) {
+				mm.DrawLayer(screen, i)
+			})
 		}
 	}
 
-	p.drawPlayer(screen)
+	for i := 0; i < mm.LayerCount(); i++ {
+		if mm.LayerPass(i) != LAYER_PASS_YSORT {
+			continue
+		}
+		minRow, maxRow := mm.VisibleRows(i)
+		for row := minRow; row < maxRow; row++ {
+			p.renderQueue.Add(mm.RowSortY(i, row), func(screen *ebiten.Image) {
+				mm.DrawLayerRow(screen, i, row)
+			})
+		}
+	}
+	for _, it := range p.Items {
+		p.renderQueue.Add(it.Position.Y+ITEM_DRAW_SIZE, func(screen *ebiten.Image) {
+			it.Draw(screen, p.Camera)
+		})
+	}
+	for _, e := range p.Enemies {
+		p.renderQueue.Add(e.FootY(), func(screen *ebiten.Image) {
+			p.drawCharacter(screen, e.Character)
+		})
+	}
+	if p.Player != nil {
+		p.renderQueue.Add(p.Player.FootY(), p.drawPlayer)
+		if p.Player.IsSwinging() {
+			p.renderQueue.Add(p.swingSortY(), p.drawSwing)
+		}
+	}
+	p.renderQueue.Add(RENDER_Y_ABOVE, p.drawProjectiles)
 
-	// Centered help text
-	DrawTextAtCenter(screen, "Gameplay - press ESC to return")
+	for i := 0; i < mm.LayerCount(); i++ {
+		if mm.LayerPass(i) == LAYER_PASS_ABOVE {
+			p.renderQueue.Add(RENDER_Y_ABOVE, func(screen *ebiten.Image) {
+				mm.DrawLayer(screen, i)
+			})
+		}
+	}
 }
 
 func (p *PlayScene) drawPlayer(screen *ebiten.Image) {
 	if p.Player == nil {
 		return
 	}
-	gc := p.Player.GetGameCharType()
-	// Java used getSprite(aniIndex, faceDir)
-	sprite := gc.GetSprite(p.Player.GetAniIndex(), p.Player.GetFaceDir())
-	if sprite == nil {
-		return
-	}
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(p.Player.Position.X, p.Player.Position.Y)
-	if p.Camera != nil {
-		op.GeoM.Translate(-p.Camera.X, -p.Camera.Y)
-	}
-	screen.DrawImage(sprite, op)
+	p.drawCharacter(screen, p.Player.Character)
 }
 
 func (p *PlayScene) drawCharacter(screen *ebiten.Image, c *Character) {
@@ -261,7 +377,8 @@ func (p *PlayScene) drawCharacter(screen *ebiten.Image, c *Character) {
 		return
 	}
 	gc := c.GetGameCharType()
-	sprite := gc.GetSprite(c.GetAniIndex(), c.GetFaceDir())
+	// Java used getSprite(aniIndex, faceDir)
+	sprite := gc.GetSprite(c.Anim.Clip, c.GetAniIndex(), c.GetFaceDir())
 	if sprite == nil {
 		return
 	}
@@ -270,8 +387,26 @@ func (p *PlayScene) drawCharacter(screen *ebiten.Image, c *Character) {
 	if p.Camera != nil {
 		op.GeoM.Translate(-p.Camera.X, -p.Camera.Y)
 	}
+	if c.FlashTime > 0 || c.Health.IsDead() {
+		op.ColorScale.Scale(1, 0.35, 0.35, 1)
+	}
+	// blink while invulnerable
+	if c.Health.IsInvulnerable() && int(c.Health.invulnTime*10)%2 == 0 {
+		op.ColorScale.ScaleAlpha(0.4)
+	}
 	screen.DrawImage(sprite, op)
 }
+
+// drawHealth shows the player's HP in the top-left corner, clear of the menu button in the top-right one.
+func (p *PlayScene) drawHealth(screen *ebiten.Image) {
+	if p.Player == nil {
+		return
+	}
+	h := p.Player.Health
+	text := fmt.Sprintf("HP %d/%d", h.Current, h.Max)
+	ebitenutil.DebugPrintAt(screen, text, 4, 2)
+}
+
 func (p *PlayScene) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return 320, 128
 }
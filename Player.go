@@ -1,27 +1,16 @@
 package main
 
-// Converted from:
-// public class Player extends Character {
-//     public Player() {
-//         super(new PointF(GAME_WIDTH / 2, GAME_HEIGHT / 2), GameCharacters.PLAYER);
-//     }
-//
-//     public void update(double delta, boolean movePlayer) {
-//         if (movePlayer)
-//             updateAnimation();
-//     }
-// }
-
 // Minimal supporting types (adjust or remove if you have your own implementations)
 type PointF struct {
 	X float64
 	Y float64
 }
 
-type GameCharacter int
+// GameCharacter names a character of the registry, see CharacterDefs.
+type GameCharacter string
 
 const (
-	GameCharacterPlayer GameCharacter = iota
+	GameCharacterPlayer GameCharacter = "player"
 )
 
 const (
@@ -32,9 +21,13 @@ const (
 type Character struct {
 	Position     PointF
 	GameCharType GameCharacter
-	AniTick      int
-	AniIndex     int
+	Anim         Animator
 	FaceDir      int
+	// Hitbox is relative to Position (the top-left corner of the sprite)
+	Hitbox RectF
+	// FlashTime tints the character while it's above 0, after a hit (seconds)
+	FlashTime float64
+	Health    Health
 }
 
 const (
@@ -42,39 +35,62 @@ const (
 	FACE_DIR_UP    = 1
 	FACE_DIR_LEFT  = 2
 	FACE_DIR_RIGHT = 3
-
-	ANIM_DEFAULT_SPEED = 10
-	ANIM_AMOUNT        = 4
 )
 
+// default hitbox for 16x16 characters, leaving a little room around the body
+var DefaultCharacterHitbox = RectF{X: 3, Y: 4, W: 10, H: 12}
+
+// NewCharacter creates a character of type t, taking its hitbox and HP from the registry when it's defined there.
 func NewCharacter(pos PointF, t GameCharacter) *Character {
-	return &Character{
+	c := &Character{
 		Position:     pos,
 		GameCharType: t,
-		AniTick:      0,
-		AniIndex:     0,
+		Anim:         NewAnimator(t.Def()),
 		FaceDir:      FACE_DIR_DOWN,
+		Hitbox:       DefaultCharacterHitbox,
+	}
+	if def := t.Def(); def != nil {
+		if def.Hitbox != nil {
+			c.Hitbox = *def.Hitbox
+		}
+		if def.HP > 0 {
+			c.Health = NewHealth(def.HP, def.InvulnTime)
+		}
 	}
+	return c
 }
 
-func (c *Character) UpdateAnimation() {
-	c.AniTick++
-	if c.AniTick >= ANIM_DEFAULT_SPEED {
-		c.AniTick = 0
-		c.AniIndex++
-		if c.AniIndex >= ANIM_AMOUNT {
-			c.AniIndex = 0
-		}
+// Play switches the character's animation, see Animator.Play.
+func (c *Character) Play(clip string) {
+	c.Anim.Play(clip)
+}
+
+// MoveSpeed returns the character's speed from the registry in pixels per second, or fallback when it has none.
+func (c *Character) MoveSpeed(fallback float64) float64 {
+	if def := c.GameCharType.Def(); def != nil && def.Speed > 0 {
+		return def.Speed
 	}
+	return fallback
+}
+
+// HitboxAt returns the hitbox in world pixels as if the character stood at x, y.
+func (c *Character) HitboxAt(x, y float64) RectF {
+	return c.Hitbox.Offset(x, y)
 }
 
-func (c *Character) ResetAnimation() {
-	c.AniTick = 0
-	c.AniIndex = 0
+// Center returns the center of the character's hitbox in world pixels.
+func (c *Character) Center() (float64, float64) {
+	hb := c.HitboxAt(c.Position.X, c.Position.Y)
+	return hb.X + hb.W/2, hb.Y + hb.H/2
+}
+
+// FootY returns the world y of the bottom of the character's sprite, used for depth sorting.
+func (c *Character) FootY() float64 {
+	return c.Position.Y + SPRITE_DEFAULT_SIZE
 }
 
 func (c *Character) GetAniIndex() int {
-	return c.AniIndex
+	return c.Anim.Frame
 }
 
 func (c *Character) GetFaceDir() int {
@@ -94,14 +110,58 @@ func (c *Character) GetGameCharType() GameCharacter {
 type Player struct {
 	*Character
 	Attacking bool
+	// Inventory counts the picked up items by kind
+	Inventory map[string]int
+	// Weapon is the equipped melee weapon, nil when unarmed
+	Weapon   *Weapon
+	Shooting bool
+	// Gun is the projectile fired while Shooting, nil when the player can't shoot
+	Gun *ProjectileKind
+
+	// seconds since the current swing started, negative when not swinging
+	swingTime float64
+	// seconds until the next swing can start
+	attackCooldown float64
+	// enemies already hit by the current swing
+	swingHits []*Enemy
+	// seconds until the next shot can be fired
+	shotCooldown float64
 }
 
+// player stats used when the registry doesn't define them
+const (
+	PLAYER_SPEED  = 150
+	PLAYER_MAX_HP = 5
+	// seconds the player can't be hurt after a hit
+	PLAYER_INVULN_TIME = 1
+	// pixels the player is pushed away from an attacker
+	PLAYER_KNOCKBACK = 10
+)
+
 func NewPlayer() *Player {
-	return &Player{NewCharacter(PointF{X: GAME_WIDTH / 2, Y: GAME_HEIGHT / 2}, GameCharacterPlayer), false}
+	c := NewCharacter(PointF{X: GAME_WIDTH / 2, Y: GAME_HEIGHT / 2}, GameCharacterPlayer)
+	if c.Health.Max == 0 {
+		c.Health = NewHealth(PLAYER_MAX_HP, PLAYER_INVULN_TIME)
+	}
+	return &Player{
+		Character: c,
+		Inventory: make(map[string]int),
+		Weapon:    Weapons["sword"],
+		Gun:       ProjectileKinds["bullet"],
+		swingTime: -1,
+	}
 }
 
-func (p *Player) Update(delta float64, movePlayer bool) {
-	if movePlayer {
-		p.UpdateAnimation()
+// IsSwinging reports whether a weapon swing is in progress.
+func (p *Player) IsSwinging() bool {
+	return p.swingTime >= 0
+}
+
+func (p *Player) alreadyHit(e *Enemy) bool {
+	for _, hit := range p.swingHits {
+		if hit == e {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ProjectileTeam decides what a projectile can hit: player projectiles hit enemies and enemy
+// projectiles hit the player.
+type ProjectileTeam int
+
+const (
+	PROJECTILE_TEAM_PLAYER ProjectileTeam = iota
+	PROJECTILE_TEAM_ENEMY
+)
+
+// how many projectiles can be in flight at once
+const PROJECTILE_POOL_SIZE = 256
+
+// ProjectileKind describes a type of projectile. Speeds are in pixels per second, sizes in
+// pixels and times in seconds.
+type ProjectileKind struct {
+	Name      string
+	Speed     float64
+	Lifetime  float64
+	Size      float64
+	Damage    int
+	Knockback float64
+	// Pierce is how many targets a projectile passes through before it's destroyed, -1 for unlimited
+	Pierce int
+	// Bounces is how many times it bounces off solid tiles before it's destroyed
+	Bounces int
+	// Cooldown is the time a shooter waits between two shots
+	Cooldown float64
+	Color    color.RGBA
+}
+
+// ProjectileKinds holds every known projectile by name.
+var ProjectileKinds = map[string]*ProjectileKind{
+	"bullet":  {Name: "bullet", Speed: 220, Lifetime: 1, Size: 3, Damage: 1, Knockback: 4, Cooldown: 0.25, Color: color.RGBA{0xff, 0xe0, 0x40, 0xff}},
+	"piercer": {Name: "piercer", Speed: 260, Lifetime: 1, Size: 3, Damage: 1, Knockback: 2, Pierce: 3, Cooldown: 0.5, Color: color.RGBA{0x60, 0xd0, 0xff, 0xff}},
+	"bouncer": {Name: "bouncer", Speed: 180, Lifetime: 2, Size: 4, Damage: 1, Knockback: 4, Bounces: 3, Cooldown: 0.4, Color: color.RGBA{0x70, 0xff, 0x70, 0xff}},
+	"bone":    {Name: "bone", Speed: 110, Lifetime: 2, Size: 4, Damage: 1, Knockback: 6, Cooldown: 1.2, Color: color.RGBA{0xf0, 0xf0, 0xe0, 0xff}},
+}
+
+// Projectile is one slot of a ProjectilePool. X, Y is the center of the projectile.
+type Projectile struct {
+	Kind   *ProjectileKind
+	Team   ProjectileTeam
+	Source *Character
+	X, Y   float64
+	VX, VY float64
+	// Life is the time left before the projectile disappears
+	Life float64
+
+	active      bool
+	pierceLeft  int
+	bouncesLeft int
+	// hits are the targets hit so far, each hit once; the slot keeps the slice's storage between shots
+	hits []*Character
+}
+
+func (pr *Projectile) Active() bool {
+	return pr.active
+}
+
+func (pr *Projectile) Bounds() RectF {
+	s := pr.Kind.Size
+	return RectF{X: pr.X - s/2, Y: pr.Y - s/2, W: s, H: s}
+}
+
+func (pr *Projectile) kill() {
+	pr.active = false
+	pr.Source = nil
+	clear(pr.hits)
+	pr.hits = pr.hits[:0]
+}
+
+func (pr *Projectile) alreadyHit(c *Character) bool {
+	return slices.Contains(pr.hits, c)
+}
+
+// landHit records a hit on c and destroys the projectile once it can't pierce any more.
+func (pr *Projectile) landHit(c *Character) {
+	pr.hits = append(pr.hits, c)
+	switch {
+	case pr.pierceLeft == 0:
+		pr.kill()
+	case pr.pierceLeft > 0:
+		pr.pierceLeft--
+	}
+}
+
+// ProjectilePool is a fixed set of projectiles reused as they're fired and destroyed,
+// so firing doesn't allocate during gameplay.
+type ProjectilePool struct {
+	items []Projectile
+	// next is the slot to look at first when spawning
+	next int
+}
+
+func NewProjectilePool(size int) *ProjectilePool {
+	return &ProjectilePool{items: make([]Projectile, size)}
+}
+
+// Spawn fires a projectile of kind from x, y along dirX, dirY (normalized here).
+// It returns nil when the pool is full or the direction is zero.
+func (pp *ProjectilePool) Spawn(kind *ProjectileKind, team ProjectileTeam, source *Character, x, y, dirX, dirY float64) *Projectile {
+	d := math.Hypot(dirX, dirY)
+	if kind == nil || d == 0 {
+		return nil
+	}
+	for i := range pp.items {
+		idx := (pp.next + i) % len(pp.items)
+		pr := &pp.items[idx]
+		if pr.active {
+			continue
+		}
+		pp.next = (idx + 1) % len(pp.items)
+		hits := pr.hits[:0]
+		*pr = Projectile{
+			Kind:        kind,
+			Team:        team,
+			Source:      source,
+			X:           x,
+			Y:           y,
+			VX:          dirX / d * kind.Speed,
+			VY:          dirY / d * kind.Speed,
+			Life:        kind.Lifetime,
+			active:      true,
+			pierceLeft:  kind.Pierce,
+			bouncesLeft: kind.Bounces,
+			hits:        hits,
+		}
+		return pr
+	}
+	return nil
+}
+
+// ActiveCount returns how many projectiles are in flight.
+func (pp *ProjectilePool) ActiveCount() int {
+	n := 0
+	for i := range pp.items {
+		if pp.items[i].active {
+			n++
+		}
+	}
+	return n
+}
+
+// Clear destroys every projectile.
+func (pp *ProjectilePool) Clear() {
+	for i := range pp.items {
+		pp.items[i].kill()
+	}
+}
+
+// FireProjectile shoots a projectile of kind from the center of c along dirX, dirY.
+func (p *PlayScene) FireProjectile(c *Character, team ProjectileTeam, kind *ProjectileKind, dirX, dirY float64) *Projectile {
+	if p.projectiles == nil {
+		return nil
+	}
+	x, y := c.Center()
+	return p.projectiles.Spawn(kind, team, c, x, y, dirX, dirY)
+}
+
+// projectileBlocked reports whether r overlaps solid tiles.
+func (p *PlayScene) projectileBlocked(r RectF) bool {
+	return p.MapManager != nil && !p.MapManager.CanMoveHitbox(r)
+}
+
+// updateProjectiles moves every projectile one axis at a time, so bouncing projectiles
+// reflect off the side of the wall they hit, then applies their hits.
+func (p *PlayScene) updateProjectiles(delta float64) {
+	if p.projectiles == nil {
+		return
+	}
+	for i := range p.projectiles.items {
+		pr := &p.projectiles.items[i]
+		if !pr.active {
+			continue
+		}
+		pr.Life -= delta
+		if pr.Life <= 0 {
+			pr.kill()
+			continue
+		}
+
+		pr.X += pr.VX * delta
+		if p.projectileBlocked(pr.Bounds()) {
+			if pr.bouncesLeft == 0 {
+				pr.kill()
+				continue
+			}
+			pr.bouncesLeft--
+			pr.X -= pr.VX * delta
+			pr.VX = -pr.VX
+		}
+		pr.Y += pr.VY * delta
+		if p.projectileBlocked(pr.Bounds()) {
+			if pr.bouncesLeft == 0 {
+				pr.kill()
+				continue
+			}
+			pr.bouncesLeft--
+			pr.Y -= pr.VY * delta
+			pr.VY = -pr.VY
+		}
+
+		p.projectileHits(pr)
+	}
+}
+
+// projectileHits applies the hits of pr on the characters of the other team.
+func (p *PlayScene) projectileHits(pr *Projectile) {
+	bounds := pr.Bounds()
+	switch pr.Team {
+	case PROJECTILE_TEAM_PLAYER:
+		for _, e := range p.Enemies {
+			if !e.IsAlive() || pr.alreadyHit(e.Character) {
+				continue
+			}
+			if bounds.Intersects(e.HitboxAt(e.Position.X, e.Position.Y)) {
+				// knock the enemy back along the projectile's path
+				e.Hit(pr.Kind.Damage, pr.X-pr.VX, pr.Y-pr.VY, pr.Kind.Knockback)
+				pr.landHit(e.Character)
+				if !pr.active {
+					return
+				}
+			}
+		}
+	case PROJECTILE_TEAM_ENEMY:
+		pl := p.Player
+		if pl == nil || pr.alreadyHit(pl.Character) {
+			return
+		}
+		if bounds.Intersects(pl.HitboxAt(pl.Position.X, pl.Position.Y)) {
+			source := pr.Source
+			if source == nil {
+				source = pl.Character
+			}
+			p.hurtPlayer(source, pr.Kind.Damage)
+			pr.landHit(pl.Character)
+		}
+	}
+}
+
+// projectileImg is a white pixel scaled and tinted to draw projectiles.
+var projectileImg *ebiten.Image
+
+func (p *PlayScene) drawProjectiles(screen *ebiten.Image) {
+	if p.projectiles == nil {
+		return
+	}
+	if projectileImg == nil {
+		projectileImg = ebiten.NewImage(1, 1)
+		projectileImg.Fill(color.White)
+	}
+	var op ebiten.DrawImageOptions
+	for i := range p.projectiles.items {
+		pr := &p.projectiles.items[i]
+		if !pr.active {
+			continue
+		}
+		b := pr.Bounds()
+		op.GeoM.Reset()
+		op.GeoM.Scale(b.W, b.H)
+		op.GeoM.Translate(b.X, b.Y)
+		if p.Camera != nil {
+			op.GeoM.Translate(-p.Camera.X, -p.Camera.Y)
+		}
+		op.ColorScale.Reset()
+		op.ColorScale.ScaleWithColor(pr.Kind.Color)
+		screen.DrawImage(projectileImg, &op)
+	}
+}
+
+// faceVector returns the unit vector of a facing direction.
+func faceVector(faceDir int) (float64, float64) {
+	switch faceDir {
+	case FACE_DIR_RIGHT:
+		return 1, 0
+	case FACE_DIR_LEFT:
+		return -1, 0
+	case FACE_DIR_UP:
+		return 0, -1
+	default:
+		return 0, 1
+	}
+}
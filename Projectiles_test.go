@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// projectileScene is a 10x10 open map with a solid column at tile x = 8.
+func projectileScene() *PlayScene {
+	tm := syntheticTilemap(10, 10)
+	for i := range tm.Layers[0].Data {
+		tm.Layers[0].Data[i] = 1
+		if i%10 == 8 {
+			tm.Layers[0].Data[i] = 2
+		}
+	}
+	tm.Tilesets[0].Tiles = []TilesetTileJSON{{ID: 1, Properties: TiledProperties{{Name: "solid", Type: "bool", Value: true}}}}
+	return &PlayScene{
+		MapManager:  NewTileMapManager(tm, nil),
+		rng:         rand.New(rand.NewSource(1)),
+		projectiles: NewProjectilePool(8),
+	}
+}
+
+func TestProjectileBouncesOffWalls(t *testing.T) {
+	p := projectileScene()
+	kind := &ProjectileKind{Speed: 60, Lifetime: 2, Size: 2, Damage: 1, Bounces: 1}
+	pr := p.projectiles.Spawn(kind, PROJECTILE_TEAM_PLAYER, nil, 100, 40, 1, 0)
+	if pr == nil {
+		t.Fatal("spawn failed")
+	}
+	for i := 0; i < 60 && pr.Active(); i++ {
+		p.updateProjectiles(1.0 / 60)
+	}
+	if !pr.Active() || pr.VX >= 0 {
+		t.Fatalf("projectile should have bounced off the wall, active %v vx %v", pr.Active(), pr.VX)
+	}
+
+	kind.Bounces = 0
+	pr = p.projectiles.Spawn(kind, PROJECTILE_TEAM_PLAYER, nil, 100, 40, 1, 0)
+	for i := 0; i < 60 && pr.Active(); i++ {
+		p.updateProjectiles(1.0 / 60)
+	}
+	if pr.Active() {
+		t.Error("projectile without bounces should be destroyed by the wall")
+	}
+}
+
+func TestProjectilePierce(t *testing.T) {
+	p := projectileScene()
+	for _, x := range []float64{30, 50, 70} {
+		c := NewCharacter(PointF{X: x, Y: 32}, GameCharacterSkeleton)
+		e := NewEnemy(c)
+		e.Health.SetMax(10)
+		p.Enemies = append(p.Enemies, e)
+	}
+	kind := &ProjectileKind{Speed: 120, Lifetime: 2, Size: 2, Damage: 1, Pierce: 1}
+	pr := p.projectiles.Spawn(kind, PROJECTILE_TEAM_PLAYER, nil, 10, 40, 1, 0)
+	for i := 0; i < 60 && pr.Active(); i++ {
+		p.updateProjectiles(1.0 / 60)
+	}
+	if pr.Active() {
+		t.Fatal("projectile should be destroyed after its last pierce")
+	}
+	got := []int{p.Enemies[0].Health.Current, p.Enemies[1].Health.Current, p.Enemies[2].Health.Current}
+	if got[0] != 9 || got[1] != 9 || got[2] != 10 {
+		t.Errorf("enemy HP = %v, want [9 9 10]", got)
+	}
+}
+
+func TestProjectilePierceUnlimited(t *testing.T) {
+	p := projectileScene()
+	// a crowd on one spot, which the slow projectile overlaps for many frames
+	for i := 0; i < 12; i++ {
+		e := NewEnemy(NewCharacter(PointF{X: 40, Y: 32}, GameCharacterSkeleton))
+		e.Health.SetMax(10)
+		p.Enemies = append(p.Enemies, e)
+	}
+	kind := &ProjectileKind{Speed: 20, Lifetime: 3, Size: 2, Damage: 1, Pierce: -1}
+	pr := p.projectiles.Spawn(kind, PROJECTILE_TEAM_PLAYER, nil, 30, 40, 1, 0)
+	for i := 0; i < 180 && pr.Active(); i++ {
+		p.updateProjectiles(1.0 / 60)
+	}
+	for i, e := range p.Enemies {
+		if e.Health.Current != 9 {
+			t.Errorf("enemy %d has %d HP, want 9 after one hit", i, e.Health.Current)
+		}
+	}
+}
+
+func TestProjectilePoolDoesNotAllocate(t *testing.T) {
+	p := projectileScene()
+	kind := &ProjectileKind{Speed: 200, Lifetime: 0.1, Size: 2, Damage: 1}
+	allocs := testing.AllocsPerRun(200, func() {
+		p.projectiles.Spawn(kind, PROJECTILE_TEAM_PLAYER, nil, 40, 40, 1, 1)
+		p.updateProjectiles(1.0 / 60)
+	})
+	if allocs != 0 {
+		t.Errorf("spawning and updating allocated %v times per tick", allocs)
+	}
+	if n := p.projectiles.ActiveCount(); n > 8 {
+		t.Errorf("pool grew to %d projectiles", n)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// sort Ys of what's drawn under and over everything sorted by position, like whole map layers
+var (
+	RENDER_Y_BELOW = math.Inf(-1)
+	RENDER_Y_ABOVE = math.Inf(1)
+)
+
+// RenderQueue collects draw calls for one frame and runs them sorted by their sort Y,
+// so things lower on the screen are drawn over things above them.
+type RenderQueue struct {
+	items []renderItem
+}
+
+type renderItem struct {
+	sortY float64
+	draw  func(screen *ebiten.Image)
+}
+
+// Add queues a draw call. Calls with the same sort Y are drawn in the order they were added.
+func (q *RenderQueue) Add(sortY float64, draw func(screen *ebiten.Image)) {
+	q.items = append(q.items, renderItem{sortY: sortY, draw: draw})
+}
+
+// Flush draws the queued calls and empties the queue.
+func (q *RenderQueue) Flush(screen *ebiten.Image) {
+	sort.SliceStable(q.items, func(i, j int) bool {
+		return q.items[i].sortY < q.items[j].sortY
+	})
+	for i := range q.items {
+		q.items[i].draw(screen)
+		// drop the closure so it can be collected
+		q.items[i].draw = nil
+	}
+	q.items = q.items[:0]
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestRenderQueueOrder(t *testing.T) {
+	var q RenderQueue
+	var got []string
+	add := func(sortY float64, name string) {
+		q.Add(sortY, func(*ebiten.Image) { got = append(got, name) })
+	}
+	add(40, "tree")
+	add(RENDER_Y_ABOVE, "roof")
+	add(20, "player")
+	add(40, "enemy")
+	add(RENDER_Y_BELOW, "ground")
+	add(10, "item")
+	add(40, "swing")
+	q.Flush(nil)
+
+	want := []string{"ground", "item", "player", "tree", "enemy", "swing", "roof"}
+	if !slices.Equal(got, want) {
+		t.Errorf("drawn %v, want %v sorted by foot y, ties in the order added", got, want)
+	}
+	got = nil
+	q.Flush(nil)
+	if len(got) != 0 {
+		t.Errorf("a flushed queue drew %v again", got)
+	}
+}
+
+func TestHeadlessWorldDrawOrder(t *testing.T) {
+	tm := headlessMap()
+	layer := func(name, prop string) TilemapLayerJSON {
+		l := tm.Layers[0]
+		l.Name = name
+		l.Data = slices.Clone(l.Data)
+		l.Properties = TiledProperties{{Name: prop, Type: "bool", Value: true}}
+		return l
+	}
+	tm.Layers = append(tm.Layers, layer("trees", "ysort"), layer("roof", "above"))
+	sm := headlessManager(t, NewScriptedInput())
+	p := NewPlaySceneWithTilemap(sm, tm, DEFAULT_MAP_PATH)
+	p.Player.Position = PointF{X: 32, Y: 64}
+	e := NewEnemy(NewCharacter(PointF{X: 140, Y: 100}, GameCharacterSkeleton))
+	p.Enemies = []*Enemy{e}
+	sm.GoTo(p)
+
+	// the queue holds the ground, the tree rows, the enemy, the player, the projectiles and the roof
+	p.queueWorld()
+	minRow, maxRow := p.MapManager.VisibleRows(1)
+	rows := maxRow - minRow
+	if n := len(p.renderQueue.items); n != rows+5 {
+		t.Fatalf("%d draw calls queued for %d tree rows, want %d", n, rows, rows+5)
+	}
+	ground, enemy, player, projectiles, roof := 0, rows+1, rows+2, rows+3, rows+4
+	var drawn []int
+	for i := range p.renderQueue.items {
+		p.renderQueue.items[i].draw = func(*ebiten.Image) { drawn = append(drawn, i) }
+	}
+	p.renderQueue.Flush(nil)
+	at := func(item int) int { return slices.Index(drawn, item) }
+
+	if at(ground) != 0 {
+		t.Errorf("the ground layer was drawn at %d, want first", at(ground))
+	}
+	if at(projectiles) != len(drawn)-2 || at(roof) != len(drawn)-1 {
+		t.Errorf("the projectiles and the roof were drawn at %d and %d, want them over everything", at(projectiles), at(roof))
+	}
+	for _, c := range []struct {
+		name  string
+		item  int
+		footY float64
+	}{{"enemy", enemy, e.FootY()}, {"player", player, p.Player.FootY()}} {
+		for row := minRow; row < maxRow; row++ {
+			behind := p.MapManager.RowSortY(1, row) > c.footY
+			if tree := at(1 + row - minRow); (tree > at(c.item)) != behind {
+				t.Errorf("the %s with its feet at %v and tree row %d were drawn at %d and %d", c.name, c.footY, row, at(c.item), tree)
+			}
+		}
+	}
+	if at(player) > at(enemy) {
+		t.Error("the player, higher up, was drawn over the enemy")
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// version of the replay files written by Recorder
+const REPLAY_VERSION = 2
+
+// Replay is a recorded PlayScene: the map and seed it started from and, for every frame, the
+// actions held, the mouse, the frame time and a checksum of the scene after the frame. Feeding the same
+// frames to a scene built the same way plays it again exactly; the checksums catch where it doesn't.
+type Replay struct {
+	Version int           `json:"version"`
+	Map     string        `json:"map"`
+	Seed    int64         `json:"seed"`
+	TPS     int           `json:"tps"`
+	Frames  []ReplayFrame `json:"frames"`
+}
+
+type ReplayFrame struct {
+	// DT is the frame time read by the scene's clock, 0 when the scene didn't update, e.g. while paused
+	DT float64 `json:"dt"`
+	// Actions holds the value of every action that wasn't released
+	Actions map[Action]float64 `json:"actions,omitempty"`
+	// CursorX, CursorY and Mouse are the cursor and the left mouse button, which the buttons read
+	CursorX int    `json:"cursorX"`
+	CursorY int    `json:"cursorY"`
+	Mouse   bool   `json:"mouse,omitempty"`
+	Sum     uint64 `json:"sum"`
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Version != REPLAY_VERSION {
+		return nil, fmt.Errorf("%s: unsupported replay version %d", path, r.Version)
+	}
+	return &r, nil
+}
+
+func (r *Replay) Save(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Checksum hashes the state of the scene that the simulation decides: the clock, the player,
+// the enemies, the items and the projectiles in flight.
+func (p *PlayScene) Checksum() uint64 {
+	h := stateHash{h: fnv.New64a()}
+	h.putUint(p.Clock.Ticks)
+	if p.Player != nil {
+		h.putCharacter(p.Player.Character)
+		h.putFloat(p.Player.swingTime)
+		h.putFloat(p.Player.attackCooldown)
+		h.putFloat(p.Player.shotCooldown)
+	}
+	h.putUint(uint64(len(p.Enemies)))
+	for _, e := range p.Enemies {
+		h.putCharacter(e.Character)
+		h.putUint(uint64(e.State))
+		h.putFloat(e.stateDuration)
+	}
+	h.putUint(uint64(len(p.Items)))
+	for _, it := range p.Items {
+		h.putString(it.Kind)
+		h.putFloat(it.Position.X)
+		h.putFloat(it.Position.Y)
+	}
+	for i := range p.projectiles.items {
+		if pr := &p.projectiles.items[i]; pr.active {
+			h.putFloat(pr.X)
+			h.putFloat(pr.Y)
+			h.putFloat(pr.Life)
+		}
+	}
+	return h.h.Sum64()
+}
+
+type stateHash struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+func (s *stateHash) putUint(v uint64) {
+	binary.LittleEndian.PutUint64(s.buf[:], v)
+	s.h.Write(s.buf[:])
+}
+
+func (s *stateHash) putFloat(f float64) {
+	s.putUint(math.Float64bits(f))
+}
+
+func (s *stateHash) putString(v string) {
+	s.putUint(uint64(len(v)))
+	s.h.Write([]byte(v))
+}
+
+func (s *stateHash) putCharacter(c *Character) {
+	s.putFloat(c.Position.X)
+	s.putFloat(c.Position.Y)
+	s.putUint(uint64(c.FaceDir))
+	s.putUint(uint64(c.Health.Current))
+	s.putString(c.Anim.Clip)
+	s.putUint(uint64(c.Anim.Frame))
+}
+
+// Recorder records the first PlayScene its SceneManager shows, from its first frame until
+// the scene is removed from the stack or Stop is called. Set it as the manager's Hook.
+type Recorder struct {
+	// Path, when set, is where Stop saves the replay
+	Path string
+
+	replay  Replay
+	scene   *PlayScene
+	stopped bool
+	// frame is the frame being recorded; frames the scene clock's Frames when it began
+	frame  ReplayFrame
+	frames uint64
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{Path: path, replay: Replay{Version: REPLAY_VERSION}}
+}
+
+// Replay returns what was recorded so far.
+func (r *Recorder) Replay() *Replay {
+	return &r.replay
+}
+
+func (r *Recorder) Recording() bool {
+	return r.scene != nil && !r.stopped
+}
+
+func (r *Recorder) BeginFrame(sm *SceneManager) {
+	if r.stopped {
+		return
+	}
+	if r.scene == nil {
+		ps, ok := sm.Current().(*PlayScene)
+		if !ok {
+			return
+		}
+		r.scene = ps
+		r.replay.Map = ps.mapPath
+		r.replay.Seed = ps.Seed()
+		r.replay.TPS = ps.Clock.TPS()
+	} else if !sm.onStack(r.scene) {
+		r.Stop()
+		return
+	}
+
+	r.frame = ReplayFrame{}
+	for _, a := range Actions {
+		if v := sm.actions().Value(a); v != 0 {
+			if r.frame.Actions == nil {
+				r.frame.Actions = make(map[Action]float64)
+			}
+			r.frame.Actions[a] = v
+		}
+	}
+	in := sm.device()
+	r.frame.CursorX, r.frame.CursorY = in.CursorPosition()
+	r.frame.Mouse = in.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	r.frames = r.scene.Clock.Frames
+}
+
+func (r *Recorder) EndFrame(sm *SceneManager) {
+	if !r.Recording() {
+		return
+	}
+	if r.scene.Clock.Frames != r.frames {
+		r.frame.DT = r.scene.Clock.LastFrame
+	}
+	r.frame.Sum = r.scene.Checksum()
+	r.replay.Frames = append(r.replay.Frames, r.frame)
+}
+
+// Stop ends the recording and saves it to Path, if set.
+func (r *Recorder) Stop() {
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	if r.Path == "" || r.scene == nil {
+		return
+	}
+	if err := r.replay.Save(r.Path); err != nil {
+		log.Printf("warning: could not save replay: %v", err)
+		return
+	}
+	log.Printf("replay of %d frames saved to %s", len(r.replay.Frames), r.Path)
+}
+
+// ReplayPlayer feeds a replay's actions, mouse and frame times to the next PlayScene its
+// SceneManager shows, in place of the input, and compares the scene's checksum with the recorded
+// one after every frame. Once the frames run out the input takes over again.
+type ReplayPlayer struct {
+	replay    *Replay
+	scene     *PlayScene
+	frame     int
+	diverged  int
+	frameTime func() float64
+	// input replaces the manager's input during the replay; device is the one it had
+	input  *ScriptedInput
+	device Input
+}
+
+func NewReplayPlayer(r *Replay) *ReplayPlayer {
+	return &ReplayPlayer{replay: r, diverged: -1}
+}
+
+// Attach makes sm play the replay: scenes created from now on use the replay's seed and frame times.
+func (rp *ReplayPlayer) Attach(sm *SceneManager) {
+	rp.frameTime = sm.FrameTime
+	if rp.frameTime == nil {
+		rp.frameTime = ebitenFrameTime
+	}
+	rp.device = sm.Input
+	rp.input = NewScriptedInput()
+	sm.Seed = rp.replay.Seed
+	sm.FrameTime = rp.currentFrameTime
+	sm.Input = rp.input
+	sm.Hook = rp
+}
+
+// PlayReplay attaches a replay to sm and starts it on the map it was recorded on.
+func PlayReplay(sm *SceneManager, r *Replay) *ReplayPlayer {
+	rp := NewReplayPlayer(r)
+	rp.Attach(sm)
+	sm.GoTo(NewPlaySceneWithMap(sm, r.Map))
+	return rp
+}
+
+func (rp *ReplayPlayer) currentFrameTime() float64 {
+	if rp.Done() {
+		return rp.frameTime()
+	}
+	return rp.replay.Frames[rp.frame].DT
+}
+
+// Done reports whether every frame was played.
+func (rp *ReplayPlayer) Done() bool {
+	return rp.frame >= len(rp.replay.Frames)
+}
+
+// Divergence returns the first frame whose checksum didn't match the recording, or -1.
+func (rp *ReplayPlayer) Divergence() int {
+	return rp.diverged
+}
+
+func (rp *ReplayPlayer) BeginFrame(sm *SceneManager) {
+	if rp.Done() {
+		return
+	}
+	if rp.scene == nil {
+		ps, ok := sm.Current().(*PlayScene)
+		if !ok {
+			return
+		}
+		rp.scene = ps
+		ps.Clock.SetTPS(rp.replay.TPS)
+	}
+	frame := rp.replay.Frames[rp.frame]
+	sm.actions().Feed(frame.Actions)
+	rp.input.MoveCursor(frame.CursorX, frame.CursorY)
+	if frame.Mouse {
+		rp.input.PressMouse(ebiten.MouseButtonLeft)
+	} else {
+		rp.input.ReleaseMouse(ebiten.MouseButtonLeft)
+	}
+}
+
+func (rp *ReplayPlayer) EndFrame(sm *SceneManager) {
+	if rp.scene == nil || rp.Done() {
+		return
+	}
+	if sum := rp.scene.Checksum(); sum != rp.replay.Frames[rp.frame].Sum && rp.diverged < 0 {
+		rp.diverged = rp.frame
+		log.Printf("warning: replay diverged at frame %d", rp.frame)
+	}
+	rp.frame++
+	if rp.Done() {
+		log.Printf("replay finished after %d frames", rp.frame)
+		if sm.Hook == FrameHook(rp) {
+			sm.Hook = nil
+		}
+		if sm.Input == Input(rp.input) {
+			sm.Input = rp.device
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// recordHeadless records a headless run with a wandering enemy: the player walks right, then
+// down while attacking and shooting.
+func recordHeadless(t *testing.T) (*Replay, *PlayScene) {
+	t.Helper()
+	sm, p, in := headlessScene(t)
+	p.Enemies = append(p.Enemies, NewEnemy(NewCharacter(PointF{X: 140, Y: 40}, GameCharacterSkeleton)))
+	rec := NewRecorder("")
+	sm.Hook = rec
+
+	in.Press(ebiten.KeyD)
+	runFrames(t, sm, 40)
+	in.Press(ebiten.KeyS, ebiten.KeySpace)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 40)
+	in.ReleaseAll()
+	runFrames(t, sm, 40)
+	rec.Stop()
+	return rec.Replay(), p
+}
+
+// replayHeadless plays r on a scene built like recordHeadless's, with its enemy, until its frames run out.
+func replayHeadless(t *testing.T, r *Replay) (*ReplayPlayer, *PlayScene) {
+	t.Helper()
+	sm := headlessManager(t, NewScriptedInput())
+	rp := NewReplayPlayer(r)
+	rp.Attach(sm)
+	p := NewPlaySceneWithTilemap(sm, headlessMap(), DEFAULT_MAP_PATH)
+	p.Player.Position = PointF{X: 32, Y: 64}
+	p.Player.SetFaceDir(FACE_DIR_RIGHT)
+	p.Enemies = append(p.Enemies, NewEnemy(NewCharacter(PointF{X: 140, Y: 40}, GameCharacterSkeleton)))
+	sm.GoTo(p)
+	for i := 0; !rp.Done(); i++ {
+		if i > len(r.Frames) {
+			t.Fatal("the replay didn't finish")
+		}
+		if err := sm.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rp, p
+}
+
+func TestReplayReproducesRecording(t *testing.T) {
+	r, recorded := recordHeadless(t)
+	if len(r.Frames) != 120 {
+		t.Fatalf("recorded %d frames, want 120", len(r.Frames))
+	}
+
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp, p := replayHeadless(t, loaded)
+	if d := rp.Divergence(); d >= 0 {
+		t.Fatalf("replay diverged at frame %d", d)
+	}
+	if p.Player.Position != recorded.Player.Position || p.Enemies[0].Position != recorded.Enemies[0].Position {
+		t.Errorf("replay ended with player %v enemy %v, recording with player %v enemy %v",
+			p.Player.Position, p.Enemies[0].Position, recorded.Player.Position, recorded.Enemies[0].Position)
+	}
+	if p.Checksum() != recorded.Checksum() {
+		t.Error("the replayed scene's checksum differs from the recorded one")
+	}
+}
+
+func TestReplayDetectsDivergence(t *testing.T) {
+	r, _ := recordHeadless(t)
+	r.Frames[30].Actions = map[Action]float64{ActionMoveUp: 1}
+
+	rp, _ := replayHeadless(t, r)
+	if d := rp.Divergence(); d != 30 {
+		t.Errorf("divergence at frame %d, want 30", d)
+	}
+}
+
+func TestReplayPauseWithMouse(t *testing.T) {
+	sm, p, in := headlessScene(t)
+	p.Enemies = append(p.Enemies, NewEnemy(NewCharacter(PointF{X: 140, Y: 40}, GameCharacterSkeleton)))
+	rec := NewRecorder("")
+	sm.Hook = rec
+
+	in.Press(ebiten.KeyD)
+	runFrames(t, sm, 20)
+	in.ReleaseAll()
+	// pause with the HUD's menu button, then resume with Escape
+	r := p.MenuButton.Bounds()
+	in.MoveCursor(r.Min.X+2, r.Min.Y+2)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	in.ReleaseMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 10)
+	if _, ok := sm.Current().(*PauseScene); !ok {
+		t.Fatalf("clicking the menu button should pause the game, scene is %T", sm.Current())
+	}
+	in.Press(ebiten.KeyEscape)
+	runFrames(t, sm, 1)
+	in.ReleaseAll()
+	in.Press(ebiten.KeyS)
+	runFrames(t, sm, 20)
+	rec.Stop()
+
+	replay := rec.Replay()
+	paused := 0
+	for _, f := range replay.Frames {
+		if f.DT == 0 {
+			paused++
+		}
+	}
+	if paused == 0 {
+		t.Error("no frame was recorded with DT 0 while paused")
+	}
+
+	rp, q := replayHeadless(t, replay)
+	if d := rp.Divergence(); d >= 0 {
+		t.Fatalf("replay diverged at frame %d", d)
+	}
+	if q.Player.Position != p.Player.Position {
+		t.Errorf("replay ended at %v, recording at %v", q.Player.Position, p.Player.Position)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveGame is the player's progress: the map they're on and their state. The map's enemies
+// and items respawn when it's loaded again.
+type SaveGame struct {
+	Map       string         `json:"map"`
+	X         float64        `json:"x"`
+	Y         float64        `json:"y"`
+	FaceDir   int            `json:"faceDir"`
+	HP        int            `json:"hp"`
+	MaxHP     int            `json:"maxHp"`
+	Inventory map[string]int `json:"inventory,omitempty"`
+	// Weapon and Gun name entries of Weapons and ProjectileKinds, empty when unarmed
+	Weapon string `json:"weapon,omitempty"`
+	Gun    string `json:"gun,omitempty"`
+}
+
+// SaveGamePath returns where the game is saved, in the user's config directory.
+func SaveGamePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "save.json"
+	}
+	return filepath.Join(dir, "BulletQuest2D", "save.json")
+}
+
+// SaveGame captures the player's progress in the scene.
+func (p *PlayScene) SaveGame() *SaveGame {
+	pl := p.Player
+	s := &SaveGame{
+		Map:       p.mapPath,
+		X:         pl.Position.X,
+		Y:         pl.Position.Y,
+		FaceDir:   pl.GetFaceDir(),
+		HP:        pl.Health.Current,
+		MaxHP:     pl.Health.Max,
+		Inventory: pl.Inventory,
+	}
+	if pl.Weapon != nil {
+		s.Weapon = pl.Weapon.Name
+	}
+	if pl.Gun != nil {
+		s.Gun = pl.Gun.Name
+	}
+	return s
+}
+
+// Apply puts the saved player state into a scene of the saved map.
+func (s *SaveGame) Apply(p *PlayScene) {
+	pl := p.Player
+	pl.Position = PointF{X: s.X, Y: s.Y}
+	pl.SetFaceDir(s.FaceDir)
+	pl.Health.SetMax(s.MaxHP)
+	pl.Health.Current = s.HP
+	clear(pl.Inventory)
+	for kind, n := range s.Inventory {
+		pl.Inventory[kind] = n
+	}
+	pl.Weapon = Weapons[s.Weapon]
+	pl.Gun = ProjectileKinds[s.Gun]
+}
+
+// NewPlaySceneFromSave loads the saved map and puts the player back where they were.
+func NewPlaySceneFromSave(sm *SceneManager, s *SaveGame) *PlayScene {
+	p := NewPlaySceneWithMap(sm, s.Map)
+	s.Apply(p)
+	return p
+}
+
+func LoadSaveGame(path string) (*SaveGame, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s SaveGame
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Map == "" {
+		return nil, fmt.Errorf("%s: no map", path)
+	}
+	return &s, nil
+}
+
+// Save writes the save file, creating its directory.
+func (s *SaveGame) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
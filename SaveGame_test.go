@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestSaveGameRoundTrip(t *testing.T) {
+	_, p, _ := headlessScene(t)
+	p.Player.Position = PointF{X: 50, Y: 70}
+	p.Player.Health.Current = 2
+	p.Player.Inventory["coin"] = 3
+	p.Player.Weapon = Weapons["goldsword"]
+
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := p.SaveGame().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSaveGame(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, q, _ := headlessScene(t)
+	s.Apply(q)
+	if q.Player.Position != p.Player.Position || q.Player.Health.Current != 2 || q.Player.Health.Max != p.Player.Health.Max {
+		t.Errorf("restored player at %v with %d/%d HP, want %v with 2/%d", q.Player.Position,
+			q.Player.Health.Current, q.Player.Health.Max, p.Player.Position, p.Player.Health.Max)
+	}
+	if q.Player.Inventory["coin"] != 3 || q.Player.Weapon != Weapons["goldsword"] || q.Player.Gun != p.Player.Gun {
+		t.Errorf("restored inventory %v, weapon %v, gun %v", q.Player.Inventory, q.Player.Weapon, q.Player.Gun)
+	}
+}
+
+func TestHeadlessMenuContinue(t *testing.T) {
+	in := NewScriptedInput()
+	sm := headlessManager(t, in)
+	menu := NewMenuScene(sm)
+	if menu.ContinueButton.Enabled() {
+		t.Fatal("Continue should be disabled without a saved game")
+	}
+
+	s := &SaveGame{Map: DEFAULT_MAP_PATH, X: 50, Y: 70, FaceDir: FACE_DIR_LEFT, HP: 2, MaxHP: 5}
+	if err := s.Save(sm.SavePath); err != nil {
+		t.Fatal(err)
+	}
+	menu = NewMenuScene(sm)
+	sm.GoTo(menu)
+	r := menu.ContinueButton.Bounds()
+	in.MoveCursor(r.Min.X+2, r.Min.Y+2)
+	in.PressMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	in.ReleaseMouse(ebiten.MouseButtonLeft)
+	runFrames(t, sm, 1)
+	finishTransition(t, sm)
+
+	p, ok := sm.Current().(*PlayScene)
+	if !ok {
+		t.Fatalf("clicking Continue should load the saved game, scene is %T", sm.Current())
+	}
+	if p.Player.Position != (PointF{X: 50, Y: 70}) || p.Player.Health.Current != 2 || p.Player.Health.Max != 5 {
+		t.Errorf("continued at %v with %d/%d HP, want 50,70 with 2/5", p.Player.Position, p.Player.Health.Current, p.Player.Health.Max)
+	}
+}
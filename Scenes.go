@@ -1,116 +1,334 @@
 package main
 
 import (
+	"errors"
+	"image"
+	"io/fs"
+	"log"
 	"os"
 
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// Scene is one screen of the game. Enter and Exit are called when it's added to and removed
+// from the SceneManager's stack, Pause and Resume when another scene is pushed over it and popped.
 type Scene interface {
 	Update() error
 	Draw(screen *ebiten.Image)
 	Layout(outsideWidth, outsideHeight int) (int, int)
 	Enter()
 	Exit()
+	Pause()
+	Resume()
 }
 
+// Overlay is implemented by scenes that don't cover the whole game: DrawBelow reports whether
+// the scene beneath is drawn first, UpdateBelow whether it keeps updating. Scenes that aren't
+// overlays hide and freeze everything beneath them.
+type Overlay interface {
+	DrawBelow() bool
+	UpdateBelow() bool
+}
+
+// SceneManager runs a stack of scenes. The top scene is the current one; overlays let the
+// scenes beneath it show through and keep running.
 type SceneManager struct {
-	current Scene
+	stack []Scene
+	// Input is what scenes read the keyboard and mouse from, ebiten's when nil
+	Input Input
+	// Actions maps the input to the player's actions, DefaultBindings when nil
+	Actions *ActionMap
+	// FrameTime overrides the real time between frames of the scenes' simulation clocks, see SimClock
+	FrameTime func() float64
+	// Seed, when not 0, seeds the random numbers of new PlayScenes instead of the clock, so they play the same way
+	Seed int64
+	// Hook sees every frame around the scene's update, see Recorder and ReplayPlayer
+	Hook FrameHook
+	// View is the screen size of the latest layout
+	View Viewport
+	// SavePath is where the pause menu saves the game and the menu continues it from, SaveGamePath() when empty
+	SavePath string
+
+	transition *sceneTransition
+	// the outgoing and incoming scenes and a mask are drawn offscreen during transitions
+	offscreens [3]*ebiten.Image
 }
 
-func (sm *SceneManager) GoTo(s Scene) {
-	if sm.current != nil {
-		sm.current.Exit()
+// FrameHook is called by the SceneManager around every scene update: BeginFrame once the actions
+// were read from the input, EndFrame after the scene updated.
+type FrameHook interface {
+	BeginFrame(sm *SceneManager)
+	EndFrame(sm *SceneManager)
+}
+
+// input returns what scenes read the input from: the manager's source, or nothing during
+// transitions. A nil manager reads ebiten directly.
+func (sm *SceneManager) input() Input {
+	if sm != nil && sm.transition != nil {
+		return noInput{}
 	}
-	sm.current = s
-	if sm.current != nil {
-		sm.current.Enter()
+	return sm.device()
+}
+
+// device returns the manager's input source.
+func (sm *SceneManager) device() Input {
+	if sm == nil || sm.Input == nil {
+		return EbitenInput{}
 	}
+	return sm.Input
 }
 
-func (sm *SceneManager) Update() error {
-	if sm.current == nil {
+// noActions answers the scenes of a nil manager: nothing is ever pressed
+var noActions = NewActionMap()
+
+// actions returns the manager's action map, creating one with the default bindings on first use.
+func (sm *SceneManager) actions() *ActionMap {
+	if sm == nil {
+		return noActions
+	}
+	if sm.Actions == nil {
+		sm.Actions = NewActionMap()
+	}
+	return sm.Actions
+}
+
+func (sm *SceneManager) savePath() string {
+	if sm == nil || sm.SavePath == "" {
+		return SaveGamePath()
+	}
+	return sm.SavePath
+}
+
+// Current returns the top scene of the stack, nil when it's empty.
+func (sm *SceneManager) Current() Scene {
+	if len(sm.stack) == 0 {
 		return nil
 	}
-	return sm.current.Update()
+	return sm.stack[len(sm.stack)-1]
 }
 
-func (sm *SceneManager) Draw(screen *ebiten.Image) {
-	if sm.current == nil {
-		return
+// Depth returns the number of scenes on the stack.
+func (sm *SceneManager) Depth() int {
+	return len(sm.stack)
+}
+
+// Below returns the scene under s on the stack, nil when s is at the bottom or not on the stack.
+func (sm *SceneManager) Below(s Scene) Scene {
+	for i := len(sm.stack) - 1; i > 0; i-- {
+		if sm.stack[i] == s {
+			return sm.stack[i-1]
+		}
 	}
-	sm.current.Draw(screen)
+	return nil
 }
 
-func (sm *SceneManager) Layout(outsideWidth, outsideHeight int) (int, int) {
-	if sm.current == nil {
-		return 320, 128
+// GoTo exits every scene on the stack and leaves s alone on it.
+func (sm *SceneManager) GoTo(s Scene) {
+	for len(sm.stack) > 0 {
+		sm.Pop()
+	}
+	if s != nil {
+		sm.Push(s)
 	}
-	return sm.current.Layout(outsideWidth, outsideHeight)
 }
 
-// MenuScene: shows title and a Play button
-type MenuScene struct {
-	sm         *SceneManager
-	wasPressed bool
+// Push pauses the current scene and puts s over it.
+func (sm *SceneManager) Push(s Scene) {
+	if top := sm.Current(); top != nil {
+		top.Pause()
+	}
+	sm.stack = append(sm.stack, s)
+	s.Enter()
 }
 
-func NewMenuScene(sm *SceneManager) *MenuScene {
-	return &MenuScene{sm: sm}
+// Pop exits the current scene and resumes the one beneath. It returns the removed scene.
+func (sm *SceneManager) Pop() Scene {
+	top := sm.Current()
+	if top == nil {
+		return nil
+	}
+	sm.stack[len(sm.stack)-1] = nil
+	sm.stack = sm.stack[:len(sm.stack)-1]
+	top.Exit()
+	if below := sm.Current(); below != nil {
+		below.Resume()
+	}
+	return top
 }
 
-func (m *MenuScene) Enter() {}
-func (m *MenuScene) Exit()  {}
+// Replace exits the current scene and puts s in its place; the scenes beneath aren't resumed.
+func (sm *SceneManager) Replace(s Scene) {
+	if top := sm.Current(); top != nil {
+		sm.stack = sm.stack[:len(sm.stack)-1]
+		top.Exit()
+	}
+	sm.stack = append(sm.stack, s)
+	s.Enter()
+}
 
-func (m *MenuScene) Update() error {
-	pressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
-	mx, my := ebiten.CursorPosition()
-
-	if StartGameButton != nil && pressed && StartGameButton.Contains(mx, my) {
-		StartGameButton.SetPushed(true)
-	} else {
-		if StartGameButton != nil && StartGameButton.IsPushed() && m.wasPressed && StartGameButton.Contains(mx, my) {
-			m.sm.GoTo(NewPlayScene(m.sm))
+// lowest returns the index of the lowest scene reached from the top through overlays that let
+// the scene beneath be drawn, or updated.
+func (sm *SceneManager) lowest(below func(Overlay) bool) int {
+	i := len(sm.stack) - 1
+	for i > 0 {
+		o, ok := sm.stack[i].(Overlay)
+		if !ok || !below(o) {
+			break
 		}
-		if StartGameButton != nil {
-			StartGameButton.SetPushed(false)
+		i--
+	}
+	return i
+}
+
+func (sm *SceneManager) onStack(s Scene) bool {
+	for _, x := range sm.stack {
+		if x == s {
+			return true
 		}
 	}
+	return false
+}
 
-	// Exit button: exit program when clicked
-	if ExitGameButton != nil && pressed && ExitGameButton.Contains(mx, my) {
-		ExitGameButton.SetPushed(true)
-	} else {
-		if ExitGameButton != nil && ExitGameButton.IsPushed() && m.wasPressed && ExitGameButton.Contains(mx, my) {
-			os.Exit(0)
+// Update updates the running scenes from the bottom up. Scenes pushed during the frame
+// first update on the next one, scenes removed during it aren't updated anymore.
+// While a transition runs the scenes see no input.
+func (sm *SceneManager) Update() error {
+	if len(sm.stack) == 0 {
+		return nil
+	}
+	sm.actions().Update(sm.input())
+	hook := sm.Hook
+	if hook != nil {
+		hook.BeginFrame(sm)
+	}
+	running := append([]Scene(nil), sm.stack[sm.lowest(Overlay.UpdateBelow):]...)
+	var err error
+	for _, s := range running {
+		if !sm.onStack(s) {
+			continue
 		}
-		if ExitGameButton != nil {
-			ExitGameButton.SetPushed(false)
+		if err = s.Update(); err != nil {
+			break
 		}
 	}
+	if hook != nil {
+		hook.EndFrame(sm)
+	}
+	sm.device().EndFrame()
+	sm.advanceTransition()
+	return err
+}
 
-	m.wasPressed = pressed
-	return nil
+func (sm *SceneManager) Draw(screen *ebiten.Image) {
+	if len(sm.stack) == 0 {
+		return
+	}
+	if sm.transition != nil {
+		sm.drawTransition(screen)
+		return
+	}
+	for _, s := range sm.stack[sm.lowest(Overlay.DrawBelow):] {
+		s.Draw(screen)
+	}
 }
 
-func (m *MenuScene) Draw(screen *ebiten.Image) {
-	DrawTextAtCenter(screen, "Bullet Quest 2D")
+// Layout is the current scene's; overlays draw on the same screen as the scenes beneath.
+func (sm *SceneManager) Layout(outsideWidth, outsideHeight int) (int, int) {
+	w, h := 320, 128
+	if top := sm.Current(); top != nil {
+		w, h = top.Layout(outsideWidth, outsideHeight)
+	}
+	sm.View = Viewport{ScreenW: w, ScreenH: h}
+	return w, h
+}
 
-	if StartGameButton != nil {
-		if StartGameButton.IsPushed() {
-			StartGameButtonPushed.Draw(screen)
-		} else {
-			StartGameButton.Draw(screen)
-		}
+// Cursor returns the mouse cursor on the logical screen; ok is false while it's on the bars
+// around the screen, outside the window or unavailable during transitions.
+func (sm *SceneManager) Cursor() (x, y int, ok bool) {
+	in := sm.input()
+	x, y = in.CursorPosition()
+	if _, blocked := in.(noInput); blocked {
+		return x, y, false
 	}
+	return x, y, sm.View.OnScreen(float64(x), float64(y))
+}
 
-	if ExitGameButton != nil {
-		if ExitGameButton.IsPushed() {
-			ExitGameButtonPushed.Draw(screen)
-		} else {
-			ExitGameButton.Draw(screen)
-		}
+// uiInput is the frame's input as the widgets of the ui package see it.
+func (sm *SceneManager) uiInput() ui.Input {
+	in := sm.input()
+	// off the screen the cursor is over no widget, even one at its edge
+	x, y, ok := sm.Cursor()
+	if !ok {
+		x, y = -1, -1
 	}
+	actions := sm.actions()
+	return ui.Input{
+		CursorX:   x,
+		CursorY:   y,
+		MouseDown: in.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+		Up:        actions.JustPressed(ActionMoveUp),
+		Down:      actions.JustPressed(ActionMoveDown),
+		Left:      actions.JustPressed(ActionMoveLeft),
+		Right:     actions.JustPressed(ActionMoveRight),
+		Confirm:   actions.JustPressed(ActionConfirm),
+	}
+}
+
+// the menu's buttons are drawn at twice the size of the atlas
+const MENU_BUTTON_SCALE = 2
+
+// MenuScene: shows title and the Play, Continue and Exit buttons
+type MenuScene struct {
+	sm *SceneManager
+	ui *ui.UI
+	// StartButton and ExitButton are drawn with the button atlas, or as text buttons without it
+	StartButton *ui.Button
+	ExitButton  *ui.Button
+	// ContinueButton loads the saved game; it's disabled when there is none
+	ContinueButton *ui.Button
+	save           *SaveGame
+}
+
+func NewMenuScene(sm *SceneManager) *MenuScene {
+	m := &MenuScene{sm: sm}
+	m.StartButton = MenuStart.NewButton("Play", MENU_BUTTON_SCALE, m.start)
+	m.ContinueButton = ui.NewButton("Continue", m.continueGame)
+	m.ExitButton = PlayingMenu.NewButton("Exit", MENU_BUTTON_SCALE, func() { os.Exit(0) })
+	save, err := LoadSaveGame(sm.savePath())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("warning: could not load the saved game: %v", err)
+	}
+	m.save = save
+	m.ContinueButton.Disabled = save == nil
+	// the buttons sit in the top-left of the screen
+	buttons := ui.NewVBox(8, m.StartButton, m.ContinueButton, m.ExitButton)
+	m.ui = ui.New(ui.NewAnchor(ui.Anchored{Widget: buttons, At: ui.ANCHOR_TOP_LEFT, Offset: image.Pt(10, 10)}))
+	m.ui.Layout(image.Rect(0, 0, 320, 128))
+	return m
+}
+
+func (m *MenuScene) start() {
+	m.sm.GoToWith(NewPlayScene(m.sm), Transition{Kind: TRANSITION_FADE, Duration: TRANSITION_FADE_TIME})
+}
+
+func (m *MenuScene) continueGame() {
+	m.sm.GoToWith(NewPlaySceneFromSave(m.sm, m.save), Transition{Kind: TRANSITION_FADE, Duration: TRANSITION_FADE_TIME})
+}
+
+func (m *MenuScene) Enter()  {}
+func (m *MenuScene) Exit()   {}
+func (m *MenuScene) Pause()  {}
+func (m *MenuScene) Resume() {}
+
+func (m *MenuScene) Update() error {
+	m.ui.Update(m.sm.uiInput())
+	return nil
+}
+
+func (m *MenuScene) Draw(screen *ebiten.Image) {
+	DrawTextAtCenter(screen, "Bullet Quest 2D")
+	m.ui.Draw(screen)
 }
 
 func (m *MenuScene) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/bulletmagnet123/BulletQuest2DGOlang/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// testScene logs its hooks, updates and draws to a shared log.
+type testScene struct {
+	name        string
+	log         *[]string
+	drawBelow   bool
+	updateBelow bool
+}
+
+func (s *testScene) Update() error             { *s.log = append(*s.log, s.name+".update"); return nil }
+func (s *testScene) Draw(screen *ebiten.Image) { *s.log = append(*s.log, s.name+".draw") }
+func (s *testScene) Layout(w, h int) (int, int) {
+	return 320, 128
+}
+func (s *testScene) Enter()            { *s.log = append(*s.log, s.name+".enter") }
+func (s *testScene) Exit()             { *s.log = append(*s.log, s.name+".exit") }
+func (s *testScene) Pause()            { *s.log = append(*s.log, s.name+".pause") }
+func (s *testScene) Resume()           { *s.log = append(*s.log, s.name+".resume") }
+func (s *testScene) DrawBelow() bool   { return s.drawBelow }
+func (s *testScene) UpdateBelow() bool { return s.updateBelow }
+
+func expectLog(t *testing.T, log *[]string, want string) {
+	t.Helper()
+	if got := strings.Join(*log, " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	*log = (*log)[:0]
+}
+
+func TestSceneStackHooks(t *testing.T) {
+	var log []string
+	sm := &SceneManager{Input: NewScriptedInput()}
+	a := &testScene{name: "a", log: &log}
+	b := &testScene{name: "b", log: &log}
+	c := &testScene{name: "c", log: &log}
+
+	sm.GoTo(a)
+	sm.Push(b)
+	expectLog(t, &log, "a.enter a.pause b.enter")
+	sm.Replace(c)
+	expectLog(t, &log, "b.exit c.enter")
+	if sm.Below(c) != Scene(a) || sm.Depth() != 2 {
+		t.Fatal("replace should keep the scene beneath")
+	}
+	if sm.Pop() != Scene(c) {
+		t.Fatal("pop should return the top scene")
+	}
+	expectLog(t, &log, "c.exit a.resume")
+	sm.Push(b)
+	sm.GoTo(c)
+	expectLog(t, &log, "a.pause b.enter b.exit a.resume a.exit c.enter")
+	if sm.Depth() != 1 {
+		t.Errorf("GoTo left %d scenes", sm.Depth())
+	}
+}
+
+func TestSceneOverlays(t *testing.T) {
+	var log []string
+	sm := &SceneManager{Input: NewScriptedInput()}
+	game := &testScene{name: "game", log: &log}
+	hud := &testScene{name: "hud", log: &log, drawBelow: true, updateBelow: true}
+	pause := &testScene{name: "pause", log: &log, drawBelow: true}
+	sm.GoTo(game)
+	sm.Push(hud)
+	log = log[:0]
+
+	sm.Update()
+	sm.Draw(nil)
+	expectLog(t, &log, "game.update hud.update game.draw hud.draw")
+
+	// an overlay that freezes the scenes beneath still shows them
+	sm.Push(pause)
+	log = log[:0]
+	sm.Update()
+	sm.Draw(nil)
+	expectLog(t, &log, "pause.update game.draw hud.draw pause.draw")
+
+	// a full scene hides everything beneath
+	sm.Push(&testScene{name: "menu", log: &log})
+	log = log[:0]
+	sm.Update()
+	sm.Draw(nil)
+	expectLog(t, &log, "menu.update menu.draw")
+}
+
+func TestOverlayMenusFitScreen(t *testing.T) {
+	sm, p, _ := headlessScene(t)
+	pause := NewPauseScene(sm, p)
+	pause.setMessage("Could not save")
+	screen := image.Rect(0, 0, 320, 128)
+	for name, u := range map[string]*ui.UI{"pause": pause.ui, "settings": NewSettingsScene(sm).ui} {
+		column := u.Root.(*ui.Anchor).Items[0].Widget.Bounds()
+		if !column.In(screen) {
+			t.Errorf("the %s menu at %v doesn't fit on the screen", name, column)
+		}
+	}
+	column := pause.ui.Root.(*ui.Anchor).Items[0].Widget.Bounds()
+	if msg := pause.message.Bounds(); !msg.In(screen) || msg.Overlaps(column) {
+		t.Errorf("the pause message at %v is off screen or over the menu at %v", msg, column)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// SpawnFunc places the entity described by a map object into the scene.
+type SpawnFunc func(p *PlayScene, obj *TiledObjectJSON)
+
+// spawners maps an object's type (set in Tiled) to the function spawning it.
+// Objects of other types are ignored, so maps can carry editor-only markers.
+// Objects whose type names an enemy of the character registry spawn that enemy.
+var spawners = map[string]SpawnFunc{
+	"player":  spawnPlayer,
+	"item":    spawnItem,
+	"trigger": spawnTrigger,
+}
+
+// RegisterSpawner adds (or replaces) the spawn function for an object type.
+func RegisterSpawner(kind string, fn SpawnFunc) {
+	spawners[strings.ToLower(kind)] = fn
+}
+
+// spawnObjects walks every object layer of the loaded map and spawns its objects.
+// It returns whether the map placed the player.
+func (p *PlayScene) spawnObjects() bool {
+	if p.tilemapJSON == nil {
+		return false
+	}
+	playerPlaced := false
+	for li := range p.tilemapJSON.Layers {
+		layer := &p.tilemapJSON.Layers[li]
+		if layer.Type != LAYER_TYPE_OBJECT {
+			continue
+		}
+		for oi := range layer.Objects {
+			obj := &layer.Objects[oi]
+			kind := strings.ToLower(obj.Kind())
+			fn, ok := spawners[kind]
+			if !ok {
+				def := GameCharacter(kind).Def()
+				if def == nil || !def.Enemy {
+					continue
+				}
+				fn = spawnEnemy
+			}
+			fn(p, obj)
+			if kind == "player" {
+				playerPlaced = true
+			}
+		}
+	}
+	return playerPlaced
+}
+
+// characterPosAt converts a spawn anchor (where the character's feet are) into the
+// character's Position, which is the top-left corner of its sprite.
+func characterPosAt(anchor PointF) PointF {
+	return PointF{X: anchor.X - SPRITE_DEFAULT_SIZE/2, Y: anchor.Y - SPRITE_DEFAULT_SIZE}
+}
+
+func spawnPlayer(p *PlayScene, obj *TiledObjectJSON) {
+	if p.Player == nil {
+		p.Player = NewPlayer()
+	}
+	p.Player.Position = characterPosAt(obj.Anchor())
+	p.Player.SetFaceDir(faceDirProperty(obj.Properties, p.Player.GetFaceDir()))
+}
+
+// spawnEnemy spawns the enemy of the character registry named by the object's type.
+func spawnEnemy(p *PlayScene, obj *TiledObjectJSON) {
+	c := NewCharacter(characterPosAt(obj.Anchor()), GameCharacter(strings.ToLower(obj.Kind())))
+	c.SetFaceDir(faceDirProperty(obj.Properties, c.GetFaceDir()))
+	e := NewEnemy(c)
+	e.Health.SetMax(obj.Properties.Int("hp", e.Health.Max))
+	if name := obj.Properties.String("projectile", ""); name != "" {
+		if e.Projectile = ProjectileKinds[name]; e.Projectile == nil {
+			log.Printf("warning: map object %d: unknown projectile %q", obj.ID, name)
+		}
+	}
+	p.Enemies = append(p.Enemies, e)
+}
+
+func spawnItem(p *PlayScene, obj *TiledObjectJSON) {
+	kind := obj.Properties.String("item", obj.Name)
+	item := NewItem(kind, characterPosAt(obj.Anchor()))
+	if item == nil {
+		log.Printf("warning: map object %d: unknown item %q", obj.ID, kind)
+		return
+	}
+	p.Items = append(p.Items, item)
+}
+
+func spawnTrigger(p *PlayScene, obj *TiledObjectJSON) {
+	action := obj.Properties.String("action", "")
+	if _, ok := triggerActions[action]; !ok {
+		log.Printf("warning: map object %d: unknown trigger action %q", obj.ID, action)
+		return
+	}
+	p.Triggers = append(p.Triggers, &Trigger{Object: obj, Action: action})
+}
+
+// faceDirProperty reads the optional "facing" property (down, up, left or right).
+func faceDirProperty(props TiledProperties, def int) int {
+	switch strings.ToLower(props.String("facing", "")) {
+	case "down":
+		return FACE_DIR_DOWN
+	case "up":
+		return FACE_DIR_UP
+	case "left":
+		return FACE_DIR_LEFT
+	case "right":
+		return FACE_DIR_RIGHT
+	}
+	return def
+}
+
+// Trigger is an area from the map that runs its action when the player walks into it.
+type Trigger struct {
+	Object *TiledObjectJSON
+	Action string
+	// inside is true while the player stands in the area, so the action fires once per visit
+	inside bool
+}
+
+// triggerActions maps a trigger's "action" property to what it does.
+// They're registered in init, as actions may create new scenes which spawn triggers themselves.
+var triggerActions = make(map[string]func(p *PlayScene, t *Trigger))
+
+// RegisterTriggerAction adds (or replaces) a trigger action.
+func RegisterTriggerAction(action string, fn func(p *PlayScene, t *Trigger)) {
+	triggerActions[action] = fn
+}
+
+func init() {
+	RegisterTriggerAction("loadmap", loadMapTrigger)
+}
+
+// loadMapTrigger switches to the map in the trigger's "map" property, relative to the current map.
+// The player keeps their health, inventory and weapons and starts at the new map's spawn.
+func loadMapTrigger(p *PlayScene, t *Trigger) {
+	target := t.Object.Properties.String("map", "")
+	if target == "" {
+		log.Printf("warning: loadmap trigger %d has no map property", t.Object.ID)
+		return
+	}
+	next := NewPlaySceneWithMap(p.sm, resolvePath(filepath.Dir(p.mapPath), target))
+	carried := p.SaveGame()
+	carried.X, carried.Y = next.Player.Position.X, next.Player.Position.Y
+	carried.Apply(next)
+	p.sm.GoToWith(next, Transition{Kind: TRANSITION_WIPE, Duration: TRANSITION_WIPE_TIME, Dir: p.Player.GetFaceDir()})
+}
+
+// updateTriggers fires the triggers the player's feet just entered, until one leaves the scene.
+// With fire false it only records where the player stands, so spawning inside a trigger doesn't set it off.
+func (p *PlayScene) updateTriggers(fire bool) {
+	if p.Player == nil {
+		return
+	}
+	hb := p.Player.HitboxAt(p.Player.Position.X, p.Player.Position.Y)
+	footX, footY := hb.X+hb.W/2, hb.Y+hb.H
+	for _, t := range p.Triggers {
+		inside := t.Object.Contains(footX, footY)
+		if fire && inside && !t.inside {
+			triggerActions[t.Action](p, t)
+		}
+		t.inside = inside
+		if p.exited {
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// tile layers are baked in square chunks of this many tiles
+const CACHE_CHUNK_TILES = 16
+
+// layerCache holds the baked chunks of one tile layer, keyed by chunk coordinates
+// (tile coordinates divided by CACHE_CHUNK_TILES, rounded down).
+type layerCache struct {
+	chunks map[image.Point]*cachedChunk
+}
+
+// cachedChunk is a pre-rendered square of tiles. Tiles that can't be baked, because they're
+// animated or don't fit in their grid cell, are drawn every frame on top of the image.
+type cachedChunk struct {
+	// img is nil when the chunk has no bakeable tiles
+	img   *ebiten.Image
+	live  []image.Point
+	dirty bool
+}
+
+func cacheKey(tx, ty int) image.Point {
+	return image.Pt(floorDiv(tx, CACHE_CHUNK_TILES), floorDiv(ty, CACHE_CHUNK_TILES))
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// invalidate marks the chunk holding tile tx, ty for rebaking.
+func (c *layerCache) invalidate(tx, ty int) {
+	if cc, ok := c.chunks[cacheKey(tx, ty)]; ok {
+		cc.dirty = true
+	}
+}
+
+// drawCachedLayer draws the chunks of a layer that intersect visible (in tiles), baking them
+// the first time they're seen or after they were invalidated.
+func (m *TileMapManager) drawCachedLayer(screen *ebiten.Image, index int, visible image.Rectangle) {
+	layer := &m.tilemap.Layers[index]
+	cache := &m.caches[index]
+	if cache.chunks == nil {
+		cache.chunks = make(map[image.Point]*cachedChunk)
+	}
+
+	area := visible.Intersect(layer.TileBounds())
+	if area.Empty() {
+		return
+	}
+	minKey := cacheKey(area.Min.X, area.Min.Y)
+	maxKey := cacheKey(area.Max.X-1, area.Max.Y-1)
+
+	originX, originY := m.layerOrigin(layer)
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(layer.Opacity))
+
+	for ky := minKey.Y; ky <= maxKey.Y; ky++ {
+		for kx := minKey.X; kx <= maxKey.X; kx++ {
+			key := image.Pt(kx, ky)
+			cc := cache.chunks[key]
+			if cc == nil {
+				cc = &cachedChunk{dirty: true}
+				cache.chunks[key] = cc
+			}
+			if cc.dirty {
+				m.bakeChunk(layer, key, cc)
+			}
+
+			if cc.img != nil {
+				opts.GeoM.Reset()
+				opts.GeoM.Translate(
+					float64(kx*CACHE_CHUNK_TILES*m.tileW)+originX,
+					float64(ky*CACHE_CHUNK_TILES*m.tileH)+originY,
+				)
+				screen.DrawImage(cc.img, opts)
+			}
+			for _, t := range cc.live {
+				m.drawTile(screen, layer.TileAt(t.X, t.Y), t.X, t.Y, originX, originY, opts)
+			}
+		}
+	}
+}
+
+// bakeChunk renders the bakeable tiles of a chunk into its image and collects the others.
+func (m *TileMapManager) bakeChunk(layer *TilemapLayerJSON, key image.Point, cc *cachedChunk) {
+	cc.dirty = false
+	cc.live = cc.live[:0]
+	if cc.img != nil {
+		cc.img.Clear()
+	}
+
+	minX, minY := key.X*CACHE_CHUNK_TILES, key.Y*CACHE_CHUNK_TILES
+	// tiles are drawn relative to the chunk's top-left corner
+	originX := -float64(minX * m.tileW)
+	originY := -float64(minY * m.tileH)
+	opts := &ebiten.DrawImageOptions{}
+
+	for ty := minY; ty < minY+CACHE_CHUNK_TILES; ty++ {
+		for tx := minX; tx < minX+CACHE_CHUNK_TILES; tx++ {
+			raw := layer.TileAt(tx, ty)
+			gid, _ := SplitGID(raw)
+			if gid == 0 {
+				continue
+			}
+			ts, localID := m.tilemap.TilesetForGID(gid)
+			if ts == nil || ts.Img == nil {
+				continue
+			}
+			if ts.IsAnimated(localID) || ts.TileWidth != m.tileW || ts.TileHeight != m.tileH {
+				cc.live = append(cc.live, image.Pt(tx, ty))
+				continue
+			}
+			if cc.img == nil {
+				cc.img = ebiten.NewImage(CACHE_CHUNK_TILES*m.tileW, CACHE_CHUNK_TILES*m.tileH)
+			}
+			m.drawTile(cc.img, raw, tx, ty, originX, originY, opts)
+		}
+	}
+}
@@ -1,22 +1,345 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Tiled layer types
+const (
+	LAYER_TYPE_TILE   = "tilelayer"
+	LAYER_TYPE_OBJECT = "objectgroup"
+	LAYER_TYPE_IMAGE  = "imagelayer"
+	LAYER_TYPE_GROUP  = "group"
+)
+
+// Tiled stores flips and rotations in the high bits of each gid.
+// A tile rotated by 90 degrees is stored as a diagonal flip plus a horizontal or vertical flip.
+const (
+	FLIPPED_HORIZONTALLY_FLAG uint32 = 0x80000000
+	FLIPPED_VERTICALLY_FLAG   uint32 = 0x40000000
+	FLIPPED_DIAGONALLY_FLAG   uint32 = 0x20000000
+	// only used by hexagonal maps, cleared but otherwise ignored
+	ROTATED_HEXAGONAL_120_FLAG uint32 = 0x10000000
+
+	TILE_FLAGS_MASK = FLIPPED_HORIZONTALLY_FLAG | FLIPPED_VERTICALLY_FLAG | FLIPPED_DIAGONALLY_FLAG | ROTATED_HEXAGONAL_120_FLAG
 )
 
+// TileFlip holds the flip bits of a gid.
+type TileFlip uint32
+
+func (f TileFlip) Horizontal() bool { return uint32(f)&FLIPPED_HORIZONTALLY_FLAG != 0 }
+func (f TileFlip) Vertical() bool   { return uint32(f)&FLIPPED_VERTICALLY_FLAG != 0 }
+func (f TileFlip) Diagonal() bool   { return uint32(f)&FLIPPED_DIAGONALLY_FLAG != 0 }
+
+// SplitGID separates a raw gid from a layer into the tile's gid and its flip bits.
+func SplitGID(raw uint32) (uint32, TileFlip) {
+	return raw &^ TILE_FLAGS_MASK, TileFlip(raw & TILE_FLAGS_MASK)
+}
+
+// GeoM returns the transform that draws a w x h tile with these flips into the area
+// starting at 0,0. A diagonal flip swaps the tile's width and height.
+// Like Tiled, the diagonal flip is applied first, then the horizontal and vertical ones.
+func (f TileFlip) GeoM(w, h float64) ebiten.GeoM {
+	var g ebiten.GeoM
+	if f.Diagonal() {
+		// mirror along the top-left to bottom-right diagonal: (x, y) -> (y, x)
+		g.SetElement(0, 0, 0)
+		g.SetElement(0, 1, 1)
+		g.SetElement(1, 0, 1)
+		g.SetElement(1, 1, 0)
+		w, h = h, w
+	}
+	if f.Horizontal() {
+		g.Scale(-1, 1)
+		g.Translate(w, 0)
+	}
+	if f.Vertical() {
+		g.Scale(1, -1)
+		g.Translate(0, h)
+	}
+	return g
+}
+
+// TiledPropertyJSON is a single custom property as exported by Tiled.
+// Numbers are always decoded as float64, like encoding/json does.
+type TiledPropertyJSON struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// TiledProperties is the property list found on maps, layers, tilesets and tiles.
+type TiledProperties []TiledPropertyJSON
+
+func (props TiledProperties) Get(name string) (interface{}, bool) {
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Bool returns the named boolean property, or false when missing.
+func (props TiledProperties) Bool(name string) bool {
+	v, _ := props.Get(name)
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// String returns the named property as a string, or def when missing.
+func (props TiledProperties) String(name, def string) string {
+	v, ok := props.Get(name)
+	if !ok {
+		return def
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// Float returns the named numeric property, or def when missing.
+func (props TiledProperties) Float(name string, def float64) float64 {
+	v, _ := props.Get(name)
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return def
+}
+
+// Int returns the named numeric property, or def when missing.
+func (props TiledProperties) Int(name string, def int) int {
+	return int(props.Float(name, float64(def)))
+}
+
 type TilemapLayerJSON struct {
-	Data   []int `json:"data"`
-	Width  int   `json:"width"`
-	Height int   `json:"height"`
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Visible     bool            `json:"visible"`
+	Opacity     float64         `json:"opacity"`
+	OffsetX     float64         `json:"offsetx"`
+	OffsetY     float64         `json:"offsety"`
+	X           int             `json:"x"`
+	Y           int             `json:"y"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Properties  TiledProperties `json:"properties"`
+
+	// RawData is either a JSON array of gids or a base64 string, depending on Encoding.
+	RawData json.RawMessage `json:"data"`
+	// Data holds the decoded gids, row by row.
+	Data []uint32 `json:"-"`
+
+	// Infinite maps store tiles in chunks instead of Data; StartX/StartY is the
+	// top-left tile of the chunks and can be negative.
+	Chunks []TilemapChunkJSON `json:"chunks"`
+	StartX int                `json:"startx"`
+	StartY int                `json:"starty"`
+
+	// Objects holds the objects of object group layers. Their positions include the layer offset.
+	Objects []TiledObjectJSON `json:"objects"`
+
+	// Layers holds the children of group layers. They are flattened into the map's Layers on load.
+	Layers []TilemapLayerJSON `json:"layers"`
+}
+
+// TilesetTileJSON holds per-tile data of a tileset (only tiles with custom data are exported).
+type TilesetTileJSON struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	Properties TiledProperties `json:"properties"`
+	// Animation lists the frames of an animated tile; the tile's own image is not part of it
+	Animation []TileFrameJSON `json:"animation"`
+}
+
+// TileFrameJSON is one frame of a tile animation. Duration is in milliseconds.
+type TileFrameJSON struct {
+	TileID   int `json:"tileid"`
+	Duration int `json:"duration"`
+}
+
+type TilesetJSON struct {
+	FirstGID    int               `json:"firstgid"`
+	Source      string            `json:"source"`
+	Name        string            `json:"name"`
+	TileWidth   int               `json:"tilewidth"`
+	TileHeight  int               `json:"tileheight"`
+	TileCount   int               `json:"tilecount"`
+	Columns     int               `json:"columns"`
+	Margin      int               `json:"margin"`
+	Spacing     int               `json:"spacing"`
+	Image       string            `json:"image"`
+	ImageWidth  int               `json:"imagewidth"`
+	ImageHeight int               `json:"imageheight"`
+	Properties  TiledProperties   `json:"properties"`
+	Tiles       []TilesetTileJSON `json:"tiles"`
+
+	// Img is the loaded tileset image; see LoadTilesetImages.
+	Img *ebiten.Image `json:"-"`
+	// dir is the directory Image is relative to.
+	dir string
+	// animations indexes Tiles by local id for the tiles that are animated
+	animations map[int]tileAnimation
+}
+
+type tileAnimation struct {
+	frames []TileFrameJSON
+	// total duration of the animation in milliseconds
+	total int
+}
+
+// TiledObjectJSON is an object of an object group layer: a point, rectangle, ellipse,
+// polygon, polyline or tile object. Polygon and polyline points are relative to X, Y.
+type TiledObjectJSON struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Class      string          `json:"class"`
+	X          float64         `json:"x"`
+	Y          float64         `json:"y"`
+	Width      float64         `json:"width"`
+	Height     float64         `json:"height"`
+	Rotation   float64         `json:"rotation"`
+	Visible    bool            `json:"visible"`
+	Point      bool            `json:"point"`
+	Ellipse    bool            `json:"ellipse"`
+	Polygon    []PointF        `json:"polygon"`
+	Polyline   []PointF        `json:"polyline"`
+	GID        uint32          `json:"gid"`
+	Properties TiledProperties `json:"properties"`
+}
+
+// Kind returns the object's type, which Tiled 1.9 and later exports as "class" on some objects.
+func (o *TiledObjectJSON) Kind() string {
+	if o.Type != "" {
+		return o.Type
+	}
+	return o.Class
+}
+
+// Bounds returns the object's bounding box in map pixels. Tile objects are anchored at
+// their bottom-left corner; points have an empty box.
+func (o *TiledObjectJSON) Bounds() RectF {
+	points := o.Polygon
+	if len(points) == 0 {
+		points = o.Polyline
+	}
+	if len(points) > 0 {
+		minX, minY := points[0].X, points[0].Y
+		maxX, maxY := minX, minY
+		for _, pt := range points[1:] {
+			minX, maxX = math.Min(minX, pt.X), math.Max(maxX, pt.X)
+			minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+		}
+		return RectF{X: o.X + minX, Y: o.Y + minY, W: maxX - minX, H: maxY - minY}
+	}
+	if o.GID != 0 {
+		return RectF{X: o.X, Y: o.Y - o.Height, W: o.Width, H: o.Height}
+	}
+	return RectF{X: o.X, Y: o.Y, W: o.Width, H: o.Height}
+}
+
+// Anchor returns where entities spawned from this object stand: the point itself, or the
+// bottom center of any other shape.
+func (o *TiledObjectJSON) Anchor() PointF {
+	if o.Point {
+		return PointF{X: o.X, Y: o.Y}
+	}
+	b := o.Bounds()
+	return PointF{X: b.X + b.W/2, Y: b.Y + b.H}
+}
+
+// Contains reports whether the map pixel x, y is inside the object's area.
+// Points and polylines have no area.
+func (o *TiledObjectJSON) Contains(x, y float64) bool {
+	switch {
+	case o.Point || len(o.Polyline) > 0:
+		return false
+	case len(o.Polygon) > 0:
+		// even-odd rule
+		inside := false
+		px, py := x-o.X, y-o.Y
+		for i, j := 0, len(o.Polygon)-1; i < len(o.Polygon); j, i = i, i+1 {
+			a, b := o.Polygon[i], o.Polygon[j]
+			if (a.Y > py) != (b.Y > py) && px < (b.X-a.X)*(py-a.Y)/(b.Y-a.Y)+a.X {
+				inside = !inside
+			}
+		}
+		return inside
+	case o.Ellipse:
+		if o.Width <= 0 || o.Height <= 0 {
+			return false
+		}
+		rx, ry := o.Width/2, o.Height/2
+		dx, dy := (x-o.X-rx)/rx, (y-o.Y-ry)/ry
+		return dx*dx+dy*dy <= 1
+	default:
+		b := o.Bounds()
+		return x >= b.X && x < b.X+b.W && y >= b.Y && y < b.Y+b.H
+	}
+}
+
+// TilemapChunkJSON is a rectangle of tiles of an infinite map. X and Y are in tiles.
+type TilemapChunkJSON struct {
+	X       int             `json:"x"`
+	Y       int             `json:"y"`
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+	RawData json.RawMessage `json:"data"`
+	Data    []uint32        `json:"-"`
+}
+
+// Bounds returns the chunk's area in tiles.
+func (c *TilemapChunkJSON) Bounds() image.Rectangle {
+	return image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)
+}
+
+// At returns the gid at tile coordinates tx, ty (map space), or 0 outside the chunk.
+func (c *TilemapChunkJSON) At(tx, ty int) uint32 {
+	if !image.Pt(tx, ty).In(c.Bounds()) {
+		return 0
+	}
+	return c.Data[(ty-c.Y)*c.Width+(tx-c.X)]
 }
 
 type TilemapJSON struct {
-	Layers []TilemapLayerJSON `json:"layers"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	TileWidth   int                `json:"tilewidth"`
+	TileHeight  int                `json:"tileheight"`
+	Infinite    bool               `json:"infinite"`
+	Orientation string             `json:"orientation"`
+	RenderOrder string             `json:"renderorder"`
+	Properties  TiledProperties    `json:"properties"`
+	Layers      []TilemapLayerJSON `json:"layers"`
+	Tilesets    []TilesetJSON      `json:"tilesets"`
 }
 
-func NewTilemapJSON(filepath string) (*TilemapJSON, error) {
-	contents, err := os.ReadFile(filepath)
+func NewTilemapJSON(path string) (*TilemapJSON, error) {
+	contents, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +350,460 @@ func NewTilemapJSON(filepath string) (*TilemapJSON, error) {
 		return nil, err
 
 	}
+	if TilemapJSON.TileWidth <= 0 {
+		TilemapJSON.TileWidth = TILE_SIZE
+	}
+	if TilemapJSON.TileHeight <= 0 {
+		TilemapJSON.TileHeight = TILE_SIZE
+	}
+
+	layers, err := flattenLayers(TilemapJSON.Layers, layerParent{visible: true, opacity: 1})
+	if err != nil {
+		return nil, err
+	}
+	TilemapJSON.Layers = layers
+
+	TilemapJSON.resolveTilesets(filepath.Dir(path))
 
 	return &TilemapJSON, nil
 
 }
+
+// UnmarshalJSON defaults visible and opacity, which some exporters leave out.
+func (l *TilemapLayerJSON) UnmarshalJSON(b []byte) error {
+	type layerAlias TilemapLayerJSON
+	a := layerAlias{Visible: true, Opacity: 1}
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+	*l = TilemapLayerJSON(a)
+	return nil
+}
+
+type layerParent struct {
+	visible          bool
+	opacity          float64
+	offsetX, offsetY float64
+}
+
+// flattenLayers decodes tile data and replaces group layers with their children,
+// folding the group's visibility, opacity and offset into each child.
+func flattenLayers(layers []TilemapLayerJSON, parent layerParent) ([]TilemapLayerJSON, error) {
+	var out []TilemapLayerJSON
+	for _, layer := range layers {
+		if layer.Type == "" {
+			layer.Type = LAYER_TYPE_TILE
+		}
+		layer.Visible = layer.Visible && parent.visible
+		layer.Opacity *= parent.opacity
+		layer.OffsetX += parent.offsetX
+		layer.OffsetY += parent.offsetY
+
+		if layer.Type == LAYER_TYPE_GROUP {
+			children, err := flattenLayers(layer.Layers, layerParent{
+				visible: layer.Visible,
+				opacity: layer.Opacity,
+				offsetX: layer.OffsetX,
+				offsetY: layer.OffsetY,
+			})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, children...)
+			continue
+		}
+
+		if layer.Type == LAYER_TYPE_OBJECT {
+			for i := range layer.Objects {
+				layer.Objects[i].X += layer.OffsetX
+				layer.Objects[i].Y += layer.OffsetY
+			}
+		}
+
+		if layer.Type == LAYER_TYPE_TILE {
+			data, err := decodeLayerData(layer.RawData, layer.Encoding, layer.Compression)
+			if err != nil {
+				return nil, fmt.Errorf("layer %q: %w", layer.Name, err)
+			}
+			layer.Data = data
+			layer.RawData = nil
+
+			for i := range layer.Chunks {
+				chunk := &layer.Chunks[i]
+				if chunk.Data, err = decodeLayerData(chunk.RawData, layer.Encoding, layer.Compression); err != nil {
+					return nil, fmt.Errorf("layer %q chunk %d,%d: %w", layer.Name, chunk.X, chunk.Y, err)
+				}
+				chunk.RawData = nil
+				if len(chunk.Data) != chunk.Width*chunk.Height {
+					return nil, fmt.Errorf("layer %q chunk %d,%d: has %d tiles, want %d", layer.Name, chunk.X, chunk.Y, len(chunk.Data), chunk.Width*chunk.Height)
+				}
+			}
+			if len(layer.Chunks) == 0 && len(layer.Data) > 0 && len(layer.Data) != layer.Width*layer.Height {
+				return nil, fmt.Errorf("layer %q: has %d tiles, want %d", layer.Name, len(layer.Data), layer.Width*layer.Height)
+			}
+		}
+		out = append(out, layer)
+	}
+	return out, nil
+}
+
+// TileChunks returns the layer's tiles as chunks. Finite layers are returned as a single chunk
+// so callers can treat both kinds of maps the same way.
+func (l *TilemapLayerJSON) TileChunks() []TilemapChunkJSON {
+	if len(l.Chunks) > 0 || len(l.Data) == 0 {
+		return l.Chunks
+	}
+	return []TilemapChunkJSON{{Width: l.Width, Height: l.Height, Data: l.Data}}
+}
+
+// TileAt returns the raw gid (with flip bits) at tile coordinates tx, ty, or 0 when there is no tile.
+func (l *TilemapLayerJSON) TileAt(tx, ty int) uint32 {
+	if len(l.Chunks) == 0 {
+		if tx < 0 || ty < 0 || tx >= l.Width || ty >= l.Height || len(l.Data) == 0 {
+			return 0
+		}
+		return l.Data[ty*l.Width+tx]
+	}
+	for i := range l.Chunks {
+		if gid := l.Chunks[i].At(tx, ty); gid != 0 {
+			return gid
+		}
+	}
+	return 0
+}
+
+// SetTileAt replaces the raw gid at tile coordinates tx, ty. It returns false when the
+// layer has no tile storage there (outside a finite layer or its chunks).
+func (l *TilemapLayerJSON) SetTileAt(tx, ty int, gid uint32) bool {
+	p := image.Pt(tx, ty)
+	if len(l.Chunks) == 0 {
+		if !p.In(image.Rect(0, 0, l.Width, l.Height)) || len(l.Data) != l.Width*l.Height {
+			return false
+		}
+		l.Data[ty*l.Width+tx] = gid
+		return true
+	}
+	for i := range l.Chunks {
+		c := &l.Chunks[i]
+		if p.In(c.Bounds()) {
+			c.Data[(ty-c.Y)*c.Width+(tx-c.X)] = gid
+			return true
+		}
+	}
+	return false
+}
+
+// TileBounds returns the area covered by the layer's tiles, in tiles.
+func (l *TilemapLayerJSON) TileBounds() image.Rectangle {
+	var r image.Rectangle
+	for _, chunk := range l.TileChunks() {
+		r = r.Union(chunk.Bounds())
+	}
+	return r
+}
+
+// decodeLayerData decodes Tiled tile data in either CSV (plain JSON array) or base64 encoding,
+// optionally zlib or gzip compressed.
+func decodeLayerData(raw json.RawMessage, encoding, compression string) ([]uint32, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if encoding == "" || encoding == "csv" {
+		var data []uint32
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if encoding != "base64" {
+		return nil, fmt.Errorf("unsupported tile data encoding %q", encoding)
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(b)
+	switch compression {
+	case "":
+	case "zlib":
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	default:
+		return nil, fmt.Errorf("unsupported tile data compression %q", compression)
+	}
+	if b, err = io.ReadAll(r); err != nil {
+		return nil, err
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("tile data length %d is not a multiple of 4", len(b))
+	}
+
+	data := make([]uint32, len(b)/4)
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return data, nil
+}
+
+// resolveTilesets loads external tilesets (relative to the map's directory) and sorts them by firstgid.
+// A missing external tileset is not fatal: it is logged and left without tile data, so the map
+// can still be drawn with a fallback image.
+func (tm *TilemapJSON) resolveTilesets(dir string) {
+	for i := range tm.Tilesets {
+		ts := &tm.Tilesets[i]
+		ts.dir = dir
+		if ts.Source != "" {
+			path := resolvePath(dir, ts.Source)
+			ext, err := loadExternalTileset(path)
+			if err != nil {
+				log.Printf("warning: could not load tileset %s: %v", path, err)
+			} else {
+				ext.FirstGID = ts.FirstGID
+				ext.Source = ts.Source
+				ext.dir = filepath.Dir(path)
+				*ts = *ext
+			}
+		}
+		if ts.TileWidth <= 0 {
+			ts.TileWidth = tm.TileWidth
+		}
+		if ts.TileHeight <= 0 {
+			ts.TileHeight = tm.TileHeight
+		}
+		ts.indexAnimations()
+	}
+	sort.SliceStable(tm.Tilesets, func(i, j int) bool {
+		return tm.Tilesets[i].FirstGID < tm.Tilesets[j].FirstGID
+	})
+}
+
+// resolvePath resolves a path found in a map or tileset file against the file's directory.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func loadExternalTileset(path string) (*TilesetJSON, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsx":
+		return parseTilesetXML(contents)
+	default:
+		var ts TilesetJSON
+		if err := json.Unmarshal(contents, &ts); err != nil {
+			return nil, err
+		}
+		return &ts, nil
+	}
+}
+
+type tilesetXML struct {
+	Name       string `xml:"name,attr"`
+	TileWidth  int    `xml:"tilewidth,attr"`
+	TileHeight int    `xml:"tileheight,attr"`
+	TileCount  int    `xml:"tilecount,attr"`
+	Columns    int    `xml:"columns,attr"`
+	Margin     int    `xml:"margin,attr"`
+	Spacing    int    `xml:"spacing,attr"`
+	Image      struct {
+		Source string `xml:"source,attr"`
+		Width  int    `xml:"width,attr"`
+		Height int    `xml:"height,attr"`
+	} `xml:"image"`
+	Properties []propertyXML `xml:"properties>property"`
+	Tiles      []struct {
+		ID         int           `xml:"id,attr"`
+		Type       string        `xml:"type,attr"`
+		Class      string        `xml:"class,attr"`
+		Properties []propertyXML `xml:"properties>property"`
+		Frames     []struct {
+			TileID   int `xml:"tileid,attr"`
+			Duration int `xml:"duration,attr"`
+		} `xml:"animation>frame"`
+	} `xml:"tile"`
+}
+
+type propertyXML struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+// parseTilesetXML converts a .tsx tileset into the same shape as its JSON export.
+func parseTilesetXML(contents []byte) (*TilesetJSON, error) {
+	var x tilesetXML
+	if err := xml.Unmarshal(contents, &x); err != nil {
+		return nil, err
+	}
+	ts := &TilesetJSON{
+		Name:        x.Name,
+		TileWidth:   x.TileWidth,
+		TileHeight:  x.TileHeight,
+		TileCount:   x.TileCount,
+		Columns:     x.Columns,
+		Margin:      x.Margin,
+		Spacing:     x.Spacing,
+		Image:       x.Image.Source,
+		ImageWidth:  x.Image.Width,
+		ImageHeight: x.Image.Height,
+		Properties:  convertPropertiesXML(x.Properties),
+	}
+	for _, t := range x.Tiles {
+		tileType := t.Type
+		if tileType == "" {
+			tileType = t.Class
+		}
+		tile := TilesetTileJSON{
+			ID:         t.ID,
+			Type:       tileType,
+			Properties: convertPropertiesXML(t.Properties),
+		}
+		for _, f := range t.Frames {
+			tile.Animation = append(tile.Animation, TileFrameJSON{TileID: f.TileID, Duration: f.Duration})
+		}
+		ts.Tiles = append(ts.Tiles, tile)
+	}
+	return ts, nil
+}
+
+func convertPropertiesXML(in []propertyXML) TiledProperties {
+	var props TiledProperties
+	for _, p := range in {
+		raw := p.Value
+		if raw == "" {
+			// multi-line strings are stored as element text
+			raw = p.Text
+		}
+		var value interface{} = raw
+		switch p.Type {
+		case "bool":
+			value = raw == "true"
+		case "int", "float", "object":
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				value = f
+			}
+		}
+		props = append(props, TiledPropertyJSON{Name: p.Name, Type: p.Type, Value: value})
+	}
+	return props
+}
+
+// LoadTilesetImages loads every tileset's image. Tilesets whose image can't be loaded use fallback
+// (which may be nil), so maps whose tileset files aren't shipped can still be drawn.
+func (tm *TilemapJSON) LoadTilesetImages(fallback *ebiten.Image) {
+	for i := range tm.Tilesets {
+		ts := &tm.Tilesets[i]
+		if ts.Image != "" {
+			path := resolvePath(ts.dir, ts.Image)
+			if img, _, err := ebitenutil.NewImageFromFile(path); err != nil {
+				log.Printf("warning: could not load tileset image %s: %v", path, err)
+			} else {
+				ts.Img = img
+			}
+		}
+		if ts.Img == nil {
+			ts.Img = fallback
+		}
+		if ts.Img != nil && ts.Columns <= 0 {
+			ts.Columns = (ts.Img.Bounds().Dx() - 2*ts.Margin + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+		}
+	}
+}
+
+// TilesetForGID returns the tileset a gid belongs to and the tile's local id within it.
+// Flip bits are ignored.
+func (tm *TilemapJSON) TilesetForGID(gid uint32) (*TilesetJSON, int) {
+	gid, _ = SplitGID(gid)
+	for i := len(tm.Tilesets) - 1; i >= 0; i-- {
+		ts := &tm.Tilesets[i]
+		if int(gid) >= ts.FirstGID {
+			return ts, int(gid) - ts.FirstGID
+		}
+	}
+	return nil, 0
+}
+
+// SourceRect returns the area of the tileset image holding the local tile id.
+func (ts *TilesetJSON) SourceRect(localID int) image.Rectangle {
+	cols := ts.Columns
+	if cols <= 0 {
+		cols = 1
+	}
+	x := ts.Margin + (localID%cols)*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + (localID/cols)*(ts.TileHeight+ts.Spacing)
+	return image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight)
+}
+
+// Tile returns the custom data of a local tile id, or nil when it has none.
+func (ts *TilesetJSON) Tile(localID int) *TilesetTileJSON {
+	for i := range ts.Tiles {
+		if ts.Tiles[i].ID == localID {
+			return &ts.Tiles[i]
+		}
+	}
+	return nil
+}
+
+func (ts *TilesetJSON) indexAnimations() {
+	ts.animations = nil
+	for _, tile := range ts.Tiles {
+		total := 0
+		for _, f := range tile.Animation {
+			total += f.Duration
+		}
+		// a zero length animation would never advance
+		if total <= 0 {
+			continue
+		}
+		if ts.animations == nil {
+			ts.animations = make(map[int]tileAnimation)
+		}
+		ts.animations[tile.ID] = tileAnimation{frames: tile.Animation, total: total}
+	}
+}
+
+// IsAnimated reports whether the local tile id has animation frames.
+func (ts *TilesetJSON) IsAnimated(localID int) bool {
+	_, ok := ts.animations[localID]
+	return ok
+}
+
+// AnimatedTile returns the local tile id to show for localID after elapsedMs milliseconds.
+// Tiles without animation are returned unchanged.
+func (ts *TilesetJSON) AnimatedTile(localID int, elapsedMs int64) int {
+	anim, ok := ts.animations[localID]
+	if !ok {
+		return localID
+	}
+	t := int(elapsedMs % int64(anim.total))
+	for _, f := range anim.frames {
+		if t < f.Duration {
+			return f.TileID
+		}
+		t -= f.Duration
+	}
+	return anim.frames[len(anim.frames)-1].TileID
+}
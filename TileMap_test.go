@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitGID(t *testing.T) {
+	gid, flip := SplitGID(FLIPPED_HORIZONTALLY_FLAG | FLIPPED_DIAGONALLY_FLAG | ROTATED_HEXAGONAL_120_FLAG | 265)
+	if gid != 265 {
+		t.Errorf("gid = %d, want 265", gid)
+	}
+	if !flip.Horizontal() || flip.Vertical() || !flip.Diagonal() {
+		t.Errorf("flip = %#x, want horizontal and diagonal only", uint32(flip))
+	}
+
+	gid, flip = SplitGID(180)
+	if gid != 180 || flip != 0 {
+		t.Errorf("SplitGID(180) = %d, %#x, want 180, 0", gid, uint32(flip))
+	}
+}
+
+func TestTileFlipGeoM(t *testing.T) {
+	const size = 16.0
+	// centers of the tile's corner pixels
+	var (
+		tl = PointF{0.5, 0.5}
+		tr = PointF{size - 0.5, 0.5}
+		bl = PointF{0.5, size - 0.5}
+		br = PointF{size - 0.5, size - 0.5}
+	)
+
+	tests := []struct {
+		name   string
+		flags  uint32
+		wantTL PointF // where the source's top-left pixel ends up
+		wantTR PointF // where the source's top-right pixel ends up
+	}{
+		{"none", 0, tl, tr},
+		{"horizontal", FLIPPED_HORIZONTALLY_FLAG, tr, tl},
+		{"vertical", FLIPPED_VERTICALLY_FLAG, bl, br},
+		{"rotate 180", FLIPPED_HORIZONTALLY_FLAG | FLIPPED_VERTICALLY_FLAG, br, bl},
+		{"diagonal", FLIPPED_DIAGONALLY_FLAG, tl, bl},
+		{"rotate 90", FLIPPED_DIAGONALLY_FLAG | FLIPPED_HORIZONTALLY_FLAG, tr, br},
+		{"rotate 270", FLIPPED_DIAGONALLY_FLAG | FLIPPED_VERTICALLY_FLAG, bl, tl},
+		{"anti-diagonal", FLIPPED_DIAGONALLY_FLAG | FLIPPED_HORIZONTALLY_FLAG | FLIPPED_VERTICALLY_FLAG, br, tr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, flip := SplitGID(tt.flags | 1)
+			g := flip.GeoM(size, size)
+			for _, c := range []struct {
+				src, want PointF
+			}{{tl, tt.wantTL}, {tr, tt.wantTR}} {
+				x, y := g.Apply(c.src.X, c.src.Y)
+				if math.Abs(x-c.want.X) > 1e-9 || math.Abs(y-c.want.Y) > 1e-9 {
+					t.Errorf("pixel %v drawn at (%v, %v), want %v", c.src, x, y, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTileFlipGeoMNonSquare(t *testing.T) {
+	// a 16x32 tile flipped diagonally covers 32x16
+	_, flip := SplitGID(FLIPPED_DIAGONALLY_FLAG | FLIPPED_HORIZONTALLY_FLAG | 1)
+	g := flip.GeoM(16, 32)
+	for _, c := range []struct {
+		srcX, srcY, wantX, wantY float64
+	}{
+		{0, 0, 32, 0},
+		{16, 0, 32, 16},
+		{0, 32, 0, 0},
+		{16, 32, 0, 16},
+	} {
+		if x, y := g.Apply(c.srcX, c.srcY); x != c.wantX || y != c.wantY {
+			t.Errorf("corner (%v, %v) drawn at (%v, %v), want (%v, %v)", c.srcX, c.srcY, x, y, c.wantX, c.wantY)
+		}
+	}
+}
+
+// base64GIDs encodes gids the way Tiled writes base64 layer data, compressed with compression.
+func base64GIDs(t *testing.T, gids []uint32, compression string) json.RawMessage {
+	t.Helper()
+	var raw bytes.Buffer
+	for _, gid := range gids {
+		binary.Write(&raw, binary.LittleEndian, gid)
+	}
+	data := raw.Bytes()
+	if compression != "" {
+		var out bytes.Buffer
+		var w io.WriteCloser = zlib.NewWriter(&out)
+		if compression == "gzip" {
+			w = gzip.NewWriter(&out)
+		}
+		w.Write(data)
+		w.Close()
+		data = out.Bytes()
+	}
+	s, _ := json.Marshal(base64.StdEncoding.EncodeToString(data))
+	return s
+}
+
+func TestDecodeLayerData(t *testing.T) {
+	gids := []uint32{1, 0, 7, FLIPPED_HORIZONTALLY_FLAG | 3}
+	tests := []struct {
+		name                  string
+		raw                   json.RawMessage
+		encoding, compression string
+		want                  []uint32
+		wantErr               bool
+	}{
+		{name: "csv", raw: json.RawMessage(`[1, 0, 7, 2147483651]`), want: gids},
+		{name: "csv named", raw: json.RawMessage(`[1, 0, 7, 2147483651]`), encoding: "csv", want: gids},
+		{name: "no data", raw: json.RawMessage(`null`)},
+		{name: "base64", raw: base64GIDs(t, gids, ""), encoding: "base64", want: gids},
+		{name: "base64 with newlines", raw: json.RawMessage(`"\n   AQAAAAAAAAA=\n"`), encoding: "base64", want: []uint32{1, 0}},
+		{name: "zlib", raw: base64GIDs(t, gids, "zlib"), encoding: "base64", compression: "zlib", want: gids},
+		{name: "gzip", raw: base64GIDs(t, gids, "gzip"), encoding: "base64", compression: "gzip", want: gids},
+		{name: "zlib data read as gzip", raw: base64GIDs(t, gids, "zlib"), encoding: "base64", compression: "gzip", wantErr: true},
+		{name: "zstd", raw: base64GIDs(t, gids, ""), encoding: "base64", compression: "zstd", wantErr: true},
+		{name: "unknown encoding", raw: json.RawMessage(`"AQAAAA=="`), encoding: "hex", wantErr: true},
+		{name: "truncated", raw: json.RawMessage(`"AQAA"`), encoding: "base64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeLayerData(tt.raw, tt.encoding, tt.compression)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decoded %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("decoded %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const testTilesetXML = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="dungeon" tilewidth="16" tileheight="16" spacing="1" margin="2" tilecount="40" columns="8">
+ <properties>
+  <property name="biome" value="cave"/>
+ </properties>
+ <image source="dungeon.png" width="140" height="88"/>
+ <tile id="3" class="wall">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+   <property name="cost" type="float" value="2.5"/>
+   <property name="note">first line
+second line</property>
+  </properties>
+ </tile>
+ <tile id="9" type="water">
+  <animation>
+   <frame tileid="9" duration="100"/>
+   <frame tileid="10" duration="150"/>
+  </animation>
+ </tile>
+</tileset>`
+
+func TestParseTilesetXML(t *testing.T) {
+	ts, err := parseTilesetXML([]byte(testTilesetXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Name != "dungeon" || ts.TileWidth != 16 || ts.TileHeight != 16 || ts.TileCount != 40 || ts.Columns != 8 ||
+		ts.Margin != 2 || ts.Spacing != 1 {
+		t.Errorf("tileset attributes %+v", ts)
+	}
+	if ts.Image != "dungeon.png" || ts.ImageWidth != 140 || ts.ImageHeight != 88 {
+		t.Errorf("image %q %dx%d, want dungeon.png 140x88", ts.Image, ts.ImageWidth, ts.ImageHeight)
+	}
+	if got := ts.Properties.String("biome", ""); got != "cave" {
+		t.Errorf("biome = %q, want cave", got)
+	}
+
+	wall := ts.Tile(3)
+	if wall == nil || wall.Type != "wall" {
+		t.Fatalf("tile 3 = %+v, want a wall", wall)
+	}
+	if !wall.Properties.Bool("solid") || wall.Properties.Float("cost", 0) != 2.5 {
+		t.Errorf("tile 3 properties %+v", wall.Properties)
+	}
+	if got := wall.Properties.String("note", ""); got != "first line\nsecond line" {
+		t.Errorf("multi-line property = %q", got)
+	}
+
+	water := ts.Tile(9)
+	want := []TileFrameJSON{{TileID: 9, Duration: 100}, {TileID: 10, Duration: 150}}
+	if water == nil || water.Type != "water" || !slices.Equal(water.Animation, want) {
+		t.Fatalf("tile 9 = %+v, want water animated with %v", water, want)
+	}
+	ts.indexAnimations()
+	if got := ts.AnimatedTile(9, 120); got != 10 {
+		t.Errorf("tile 9 after 120ms shows %d, want 10", got)
+	}
+
+	if _, err := parseTilesetXML([]byte("<tileset")); err == nil {
+		t.Error("a broken tileset should fail to parse")
+	}
+}
+
+func TestFlattenLayers(t *testing.T) {
+	var layers []TilemapLayerJSON
+	err := json.Unmarshal([]byte(`[
+		{"name": "ground", "type": "tilelayer", "width": 2, "height": 1, "data": [1, 2]},
+		{"name": "outer", "type": "group", "opacity": 0.5, "offsetx": 8, "offsety": 4, "layers": [
+			{"name": "deco", "width": 2, "height": 1, "encoding": "base64", "data": "AwAAAAQAAAA="},
+			{"name": "inner", "type": "group", "visible": false, "offsety": 2, "layers": [
+				{"name": "spawns", "type": "objectgroup", "opacity": 0.5, "objects": [{"id": 1, "x": 10, "y": 20}]}
+			]}
+		]}
+	]`), &layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat, err := flattenLayers(layers, layerParent{visible: true, opacity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		typ              string
+		visible          bool
+		opacity          float64
+		offsetX, offsetY float64
+	}{
+		{"ground", LAYER_TYPE_TILE, true, 1, 0, 0},
+		{"deco", LAYER_TYPE_TILE, true, 0.5, 8, 4},
+		{"spawns", LAYER_TYPE_OBJECT, false, 0.25, 8, 6},
+	}
+	if len(flat) != len(tests) {
+		t.Fatalf("%d layers after flattening, want %d", len(flat), len(tests))
+	}
+	for i, tt := range tests {
+		l := flat[i]
+		if l.Name != tt.name || l.Type != tt.typ || l.Visible != tt.visible || l.Opacity != tt.opacity ||
+			l.OffsetX != tt.offsetX || l.OffsetY != tt.offsetY {
+			t.Errorf("layer %d = %q %s visible %v opacity %v offset %v,%v, want %+v",
+				i, l.Name, l.Type, l.Visible, l.Opacity, l.OffsetX, l.OffsetY, tt)
+		}
+	}
+	if !slices.Equal(flat[0].Data, []uint32{1, 2}) || !slices.Equal(flat[1].Data, []uint32{3, 4}) {
+		t.Errorf("tile data %v and %v, want [1 2] and [3 4]", flat[0].Data, flat[1].Data)
+	}
+	if obj := flat[2].Objects[0]; obj.X != 18 || obj.Y != 26 {
+		t.Errorf("object at %v,%v, want the group offsets added: 18,26", obj.X, obj.Y)
+	}
+
+	bad := []TilemapLayerJSON{{Name: "short", Type: LAYER_TYPE_TILE, Width: 3, Height: 1, RawData: json.RawMessage(`[1, 2]`)}}
+	if _, err := flattenLayers(bad, layerParent{visible: true, opacity: 1}); err == nil {
+		t.Error("a layer with fewer tiles than its size should fail")
+	}
+}
+
+func TestTilesetForGID(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dungeon.tsx"), []byte(testTilesetXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tsj := `{"name": "items", "tilewidth": 8, "tileheight": 8, "columns": 4, "image": "items.png"}`
+	if err := os.WriteFile(filepath.Join(dir, "items.tsj"), []byte(tsj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// out of order, as some exporters write them; the missing file keeps its entry
+	tm := &TilemapJSON{TileWidth: 16, TileHeight: 16, Tilesets: []TilesetJSON{
+		{FirstGID: 101, Source: "items.tsj"},
+		{FirstGID: 1, Name: "floor", Columns: 10},
+		{FirstGID: 41, Source: "dungeon.tsx"},
+		{FirstGID: 200, Source: "missing.tsx"},
+	}}
+	tm.resolveTilesets(dir)
+
+	var names []string
+	for _, ts := range tm.Tilesets {
+		names = append(names, ts.Name)
+	}
+	if !slices.Equal(names, []string{"floor", "dungeon", "items", ""}) {
+		t.Fatalf("tilesets %q, want them sorted by firstgid", names)
+	}
+	if ts := tm.Tilesets[1]; ts.FirstGID != 41 || ts.Image != "dungeon.png" || ts.dir != dir || !ts.IsAnimated(9) {
+		t.Errorf("external tsx tileset resolved to %+v", ts)
+	}
+	if ts := tm.Tilesets[2]; ts.TileWidth != 8 || ts.Columns != 4 {
+		t.Errorf("external tsj tileset resolved to %+v", ts)
+	}
+	if ts := tm.Tilesets[3]; ts.TileWidth != 16 {
+		t.Errorf("missing tileset should default to the map's tile size, got %d", ts.TileWidth)
+	}
+
+	tests := []struct {
+		gid       uint32
+		wantName  string
+		wantLocal int
+	}{
+		{1, "floor", 0},
+		{40, "floor", 39},
+		{41, "dungeon", 0},
+		{FLIPPED_VERTICALLY_FLAG | 52, "dungeon", 11},
+		{101, "items", 0},
+		{150, "items", 49},
+		{250, "", 50},
+	}
+	for _, tt := range tests {
+		ts, local := tm.TilesetForGID(tt.gid)
+		if ts == nil || ts.Name != tt.wantName || local != tt.wantLocal {
+			t.Errorf("gid %#x in tileset %v at %d, want %q at %d", tt.gid, ts, local, tt.wantName, tt.wantLocal)
+		}
+	}
+	if ts, _ := tm.TilesetForGID(0); ts != nil {
+		t.Errorf("gid 0 is in tileset %q, want none", ts.Name)
+	}
+}
+
+// chunkedLayer is an infinite layer of two 4x4 chunks left and right of x = 0, above y = 0.
+// Each tile's gid encodes its position so lookups can be checked.
+func chunkedLayer() TilemapLayerJSON {
+	chunk := func(x, y int) TilemapChunkJSON {
+		c := TilemapChunkJSON{X: x, Y: y, Width: 4, Height: 4}
+		for ty := y; ty < y+4; ty++ {
+			for tx := x; tx < x+4; tx++ {
+				c.Data = append(c.Data, chunkGID(tx, ty))
+			}
+		}
+		return c
+	}
+	return TilemapLayerJSON{Name: "ground", Type: LAYER_TYPE_TILE, Visible: true, Opacity: 1,
+		Chunks: []TilemapChunkJSON{chunk(-4, -4), chunk(0, -4)}}
+}
+
+func chunkGID(tx, ty int) uint32 { return uint32(100 + (tx+4)*10 + ty + 4) }
+
+func TestChunkedLayer(t *testing.T) {
+	l := chunkedLayer()
+	if got, want := l.TileBounds(), image.Rect(-4, -4, 4, 0); got != want {
+		t.Errorf("tile bounds %v, want %v", got, want)
+	}
+	for _, p := range []image.Point{{-4, -4}, {-1, -1}, {0, -1}, {-1, -4}, {0, -4}, {3, -1}} {
+		if got := l.TileAt(p.X, p.Y); got != chunkGID(p.X, p.Y) {
+			t.Errorf("tile at %v is %d, want %d", p, got, chunkGID(p.X, p.Y))
+		}
+	}
+	for _, p := range []image.Point{{-5, -1}, {4, -1}, {0, 0}, {-1, -5}} {
+		if got := l.TileAt(p.X, p.Y); got != 0 {
+			t.Errorf("tile at %v outside the chunks is %d", p, got)
+		}
+	}
+
+	if !l.SetTileAt(-1, -1, 7) || !l.SetTileAt(0, -1, 8) || l.TileAt(-1, -1) != 7 || l.TileAt(0, -1) != 8 {
+		t.Error("setting the tiles either side of the chunk edge failed")
+	}
+	if l.TileAt(-2, -1) != chunkGID(-2, -1) || l.TileAt(1, -1) != chunkGID(1, -1) {
+		t.Error("setting a tile changed its neighbour")
+	}
+	if l.SetTileAt(0, 0, 1) {
+		t.Error("setting a tile outside the chunks should fail")
+	}
+}
+
+func TestInfiniteMapOne(t *testing.T) {
+	tm, err := NewTilemapJSON("assets/maps/mapone.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Infinite || len(tm.Layers) != 1 {
+		t.Fatalf("mapone.json should be an infinite map of one layer, got %d layers", len(tm.Layers))
+	}
+	l := &tm.Layers[0]
+	if len(l.Chunks) != 3 || len(l.Data) != 0 {
+		t.Fatalf("%d chunks and %d tiles of data, want 3 chunks", len(l.Chunks), len(l.Data))
+	}
+	for _, c := range l.Chunks {
+		if len(c.Data) != c.Width*c.Height {
+			t.Errorf("chunk at %d,%d has %d tiles, want %d", c.X, c.Y, len(c.Data), c.Width*c.Height)
+		}
+	}
+	if got, want := l.TileBounds(), image.Rect(-16, -16, 32, 0); got != want {
+		t.Errorf("tile bounds %v, want %v", got, want)
+	}
+	if l.TileAt(-16, -16) != 90 || l.TileAt(0, -16) != 265 || l.TileAt(0, 0) != 0 {
+		t.Errorf("tiles at the chunk corners are %d, %d and %d", l.TileAt(-16, -16), l.TileAt(0, -16), l.TileAt(0, 0))
+	}
+	if m := NewTileMapManager(tm, nil); m.bounds != l.TileBounds() {
+		t.Errorf("the manager's bounds %v don't match the layer's", m.bounds)
+	}
+}
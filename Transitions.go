@@ -0,0 +1,215 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type TransitionKind int
+
+const (
+	// TRANSITION_FADE fades the outgoing scenes out to the color, then the incoming ones in from it
+	TRANSITION_FADE TransitionKind = iota
+	// TRANSITION_CROSSFADE blends the outgoing scenes into the incoming ones
+	TRANSITION_CROSSFADE
+	// TRANSITION_WIPE slides the edge of the incoming scenes over the outgoing ones, toward Dir
+	TRANSITION_WIPE
+	// TRANSITION_IRIS closes a circle of the color around the outgoing scene's focus point,
+	// then opens it around the incoming one's, see Focused
+	TRANSITION_IRIS
+)
+
+// seconds the game's own transitions take
+const (
+	TRANSITION_FADE_TIME = 0.4
+	TRANSITION_WIPE_TIME = 0.35
+	TRANSITION_IRIS_TIME = 0.9
+)
+
+// Transition describes how the SceneManager animates a change of scenes.
+type Transition struct {
+	Kind     TransitionKind
+	Duration float64
+	// Color is what fades and irises go through, black when nil
+	Color color.Color
+	// Dir is the FACE_DIR a wipe moves toward
+	Dir int
+	// OnDone is called once the transition ended and the input is back
+	OnDone func()
+}
+
+// Focused is implemented by scenes with a point of interest on screen, in screen pixels.
+type Focused interface {
+	FocusPoint() (x, y float64)
+}
+
+// sceneTransition is a transition in progress: from are the scenes that were visible before
+// the change. They've already left the stack and are only drawn.
+type sceneTransition struct {
+	Transition
+	from    []Scene
+	elapsed float64
+}
+
+func (t *sceneTransition) progress() float64 {
+	return min(1, t.elapsed/t.Duration)
+}
+
+// Transition runs change, which rearranges the scene stack with GoTo, Push, Pop or Replace,
+// behind an animated transition. The input is blocked until the transition ends, and a
+// PlayScene coming in holds its simulation until then.
+// A transition still running is finished first.
+func (sm *SceneManager) Transition(t Transition, change func()) {
+	sm.finishTransition()
+	from := append([]Scene(nil), sm.stack[sm.lowest(Overlay.DrawBelow):]...)
+	change()
+	if t.Duration <= 0 {
+		if t.OnDone != nil {
+			t.OnDone()
+		}
+		return
+	}
+	sm.transition = &sceneTransition{Transition: t, from: from}
+}
+
+// GoToWith is GoTo behind a transition.
+func (sm *SceneManager) GoToWith(s Scene, t Transition) {
+	sm.Transition(t, func() { sm.GoTo(s) })
+}
+
+// Transitioning reports whether a transition is running.
+func (sm *SceneManager) Transitioning() bool {
+	return sm.transition != nil
+}
+
+func (sm *SceneManager) finishTransition() {
+	t := sm.transition
+	if t == nil {
+		return
+	}
+	sm.transition = nil
+	if t.OnDone != nil {
+		t.OnDone()
+	}
+}
+
+// advanceTransition moves the running transition on by one frame.
+func (sm *SceneManager) advanceTransition() {
+	t := sm.transition
+	if t == nil {
+		return
+	}
+	frame := ebitenFrameTime
+	if sm.FrameTime != nil {
+		frame = sm.FrameTime
+	}
+	t.elapsed += frame()
+	if t.elapsed >= t.Duration {
+		sm.finishTransition()
+	}
+}
+
+// offscreen returns the manager's i-th offscreen image, cleared, in the size of the screen.
+func (sm *SceneManager) offscreen(i int, size image.Point) *ebiten.Image {
+	img := sm.offscreens[i]
+	if img == nil || img.Bounds().Size() != size {
+		if img != nil {
+			img.Deallocate()
+		}
+		img = ebiten.NewImage(size.X, size.Y)
+		sm.offscreens[i] = img
+	}
+	img.Clear()
+	return img
+}
+
+// drawTransition renders the outgoing and the incoming scenes offscreen and composes them on screen.
+func (sm *SceneManager) drawTransition(screen *ebiten.Image) {
+	t := sm.transition
+	size := screen.Bounds().Size()
+	from := sm.offscreen(0, size)
+	for _, s := range t.from {
+		s.Draw(from)
+	}
+	to := sm.offscreen(1, size)
+	for _, s := range sm.stack[sm.lowest(Overlay.DrawBelow):] {
+		s.Draw(to)
+	}
+
+	c := t.Color
+	if c == nil {
+		c = color.Black
+	}
+	progress := t.progress()
+	w, h := float64(size.X), float64(size.Y)
+	var op ebiten.DrawImageOptions
+	switch t.Kind {
+	case TRANSITION_FADE:
+		mask := sm.offscreen(2, size)
+		mask.Fill(c)
+		if progress < 0.5 {
+			screen.DrawImage(from, nil)
+			op.ColorScale.ScaleAlpha(float32(progress * 2))
+		} else {
+			screen.DrawImage(to, nil)
+			op.ColorScale.ScaleAlpha(float32(2 - progress*2))
+		}
+		screen.DrawImage(mask, &op)
+	case TRANSITION_CROSSFADE:
+		screen.DrawImage(from, nil)
+		op.ColorScale.ScaleAlpha(float32(progress))
+		screen.DrawImage(to, &op)
+	case TRANSITION_WIPE:
+		screen.DrawImage(from, nil)
+		if r := wipeRect(t.Dir, w, h, progress); !r.Empty() {
+			op.GeoM.Translate(float64(r.Min.X), float64(r.Min.Y))
+			screen.DrawImage(to.SubImage(r).(*ebiten.Image), &op)
+		}
+	case TRANSITION_IRIS:
+		scene, scenes := from, t.from
+		radius := 1 - progress*2
+		if progress >= 0.5 {
+			scene, scenes = to, sm.stack
+			radius = progress*2 - 1
+		}
+		x, y := w/2, h/2
+		if f, ok := topScene(scenes).(Focused); ok {
+			x, y = f.FocusPoint()
+		}
+		// the circle must uncover the corner furthest from its center when fully open
+		radius *= math.Hypot(max(x, w-x), max(y, h-y))
+
+		screen.DrawImage(scene, nil)
+		mask := sm.offscreen(2, size)
+		mask.Fill(c)
+		var path vector.Path
+		path.Arc(float32(x), float32(y), float32(radius), 0, 2*math.Pi, vector.Clockwise)
+		vector.FillPath(mask, &path, nil, &vector.DrawPathOptions{AntiAlias: true, Blend: ebiten.BlendClear})
+		screen.DrawImage(mask, nil)
+	}
+}
+
+// wipeRect returns the part of a w x h screen the incoming scenes cover, moving toward dir.
+func wipeRect(dir int, w, h, progress float64) image.Rectangle {
+	iw, ih := int(w), int(h)
+	switch dir {
+	case FACE_DIR_LEFT:
+		return image.Rect(int(w*(1-progress)), 0, iw, ih)
+	case FACE_DIR_UP:
+		return image.Rect(0, int(h*(1-progress)), iw, ih)
+	case FACE_DIR_DOWN:
+		return image.Rect(0, 0, iw, int(h*progress))
+	}
+	return image.Rect(0, 0, int(w*progress), ih)
+}
+
+func topScene(scenes []Scene) Scene {
+	if len(scenes) == 0 {
+		return nil
+	}
+	return scenes[len(scenes)-1]
+}
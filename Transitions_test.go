@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestTransitionBlocksInputUntilDone(t *testing.T) {
+	var log []string
+	in := NewScriptedInput()
+	sm := &SceneManager{Input: in, FrameTime: headlessFrameTime}
+	a := &testScene{name: "a", log: &log}
+	b := &testScene{name: "b", log: &log}
+	sm.GoTo(a)
+
+	done := 0
+	sm.GoToWith(b, Transition{Kind: TRANSITION_FADE, Duration: 10 * headlessFrame, OnDone: func() { done++ }})
+	if sm.Current() != Scene(b) || !sm.Transitioning() {
+		t.Fatal("the scenes should change at once and the transition run")
+	}
+
+	in.Press(ebiten.KeySpace)
+	for i := 0; i < 9; i++ {
+		sm.Update()
+		if sm.actions().Pressed(ActionShoot) {
+			t.Fatalf("frame %d: the input should be blocked during the transition", i)
+		}
+	}
+	if done != 0 {
+		t.Fatal("OnDone called before the transition ended")
+	}
+	sm.Update()
+	if done != 1 || sm.Transitioning() {
+		t.Fatalf("after the transition: OnDone called %d times, transitioning %v", done, sm.Transitioning())
+	}
+	sm.Update()
+	if !sm.actions().Pressed(ActionShoot) {
+		t.Error("the input should be back after the transition")
+	}
+}
+
+func TestTransitionDrawsBothScenes(t *testing.T) {
+	screen := ebiten.NewImage(320, 128)
+	for _, kind := range []TransitionKind{TRANSITION_FADE, TRANSITION_CROSSFADE, TRANSITION_WIPE, TRANSITION_IRIS} {
+		var log []string
+		sm := &SceneManager{Input: NewScriptedInput(), FrameTime: headlessFrameTime}
+		sm.GoTo(&testScene{name: "a", log: &log})
+		sm.GoToWith(&testScene{name: "b", log: &log}, Transition{Kind: kind, Duration: 1})
+		log = log[:0]
+		sm.Draw(screen)
+		expectLog(t, &log, "a.draw b.draw")
+	}
+}
+
+func TestTransitionInterruptedFinishesFirst(t *testing.T) {
+	var log []string
+	sm := &SceneManager{Input: NewScriptedInput(), FrameTime: headlessFrameTime}
+	sm.GoTo(&testScene{name: "a", log: &log})
+	var order []string
+	sm.GoToWith(&testScene{name: "b", log: &log}, Transition{Duration: 1, OnDone: func() { order = append(order, "first") }})
+	sm.GoToWith(&testScene{name: "c", log: &log}, Transition{Duration: 1, OnDone: func() { order = append(order, "second") }})
+	if len(order) != 1 || order[0] != "first" {
+		t.Errorf("starting a transition should finish the running one, got %v", order)
+	}
+}
+
+func TestTransitionHoldsIncomingPlayScene(t *testing.T) {
+	var log []string
+	sm := headlessManager(t, NewScriptedInput())
+	sm.GoTo(&testScene{name: "menu", log: &log})
+	p := NewPlaySceneWithTilemap(sm, headlessMap(), DEFAULT_MAP_PATH)
+	p.Player.Position = PointF{X: 32, Y: 64}
+	e := NewEnemy(NewCharacter(PointF{X: 42, Y: 64}, GameCharacterSkeleton))
+	p.Enemies = []*Enemy{e}
+	hp := p.Player.Health.Current
+
+	sm.GoToWith(p, Transition{Kind: TRANSITION_FADE, Duration: 30 * headlessFrame})
+	for sm.Transitioning() {
+		runFrames(t, sm, 1)
+		if p.Clock.Ticks != 0 {
+			t.Fatalf("the scene ran %d steps during the transition", p.Clock.Ticks)
+		}
+	}
+	if e.State != ENEMY_STATE_IDLE || p.Player.Health.Current != hp {
+		t.Fatalf("the enemy is in state %d and the player has %d HP after the transition", e.State, p.Player.Health.Current)
+	}
+
+	runFrames(t, sm, 60)
+	if p.Clock.Ticks == 0 || p.Player.Health.Current >= hp {
+		t.Errorf("after the transition the enemy next to the player should attack, player HP %d", p.Player.Health.Current)
+	}
+}
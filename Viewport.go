@@ -0,0 +1,19 @@
+package main
+
+// Viewport is the logical screen the current scene laid out. ebiten scales it to fit the window
+// and centers it, leaving black bars on the sides or at the top and bottom when their aspect
+// ratios differ, and reports the cursor on it, so a cursor over the bars is off the screen.
+// World coordinates are converted to and from the screen by the Camera.
+type Viewport struct {
+	// ScreenW and ScreenH are the logical screen size the current scene's Layout returned
+	ScreenW, ScreenH int
+}
+
+// OnScreen reports whether a logical screen position is on the screen rather than on the bars
+// around it or outside the window. Before the first layout every position is.
+func (v Viewport) OnScreen(x, y float64) bool {
+	if v.ScreenW <= 0 || v.ScreenH <= 0 {
+		return true
+	}
+	return x >= 0 && y >= 0 && x < float64(v.ScreenW) && y < float64(v.ScreenH)
+}
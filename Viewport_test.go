@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestViewportOnScreen(t *testing.T) {
+	v := Viewport{ScreenW: 320, ScreenH: 128}
+	for _, p := range [][2]float64{{0, 0}, {160, 64}, {319, 127}} {
+		if !v.OnScreen(p[0], p[1]) {
+			t.Errorf("%v should be on screen", p)
+		}
+	}
+	for _, p := range [][2]float64{{-1, 64}, {160, -1}, {320, 64}, {160, 128}} {
+		if v.OnScreen(p[0], p[1]) {
+			t.Errorf("%v should be off screen", p)
+		}
+	}
+	if !(Viewport{}).OnScreen(500, 500) {
+		t.Error("before the first layout the screen should be the window")
+	}
+}
+
+func TestHeadlessCursorOffScreen(t *testing.T) {
+	sm, _, in := headlessScene(t)
+	sm.Layout(1280, 720)
+
+	in.MoveCursor(160, 64)
+	if x, y, ok := sm.Cursor(); !ok || x != 160 || y != 64 {
+		t.Errorf("cursor at %d,%d on screen %v, want 160,64", x, y, ok)
+	}
+	// on the bars under the screen the cursor is off screen and hovers nothing
+	in.MoveCursor(160, 140)
+	if _, _, ok := sm.Cursor(); ok {
+		t.Error("the cursor under the screen should be off screen")
+	}
+	if ui := sm.uiInput(); ui.CursorX != -1 || ui.CursorY != -1 {
+		t.Errorf("widgets see the cursor at %d,%d off screen", ui.CursorX, ui.CursorY)
+	}
+}
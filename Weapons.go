@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// weapon icons are large images drawn down to this size in the player's hand
+const WEAPON_DRAW_SIZE = 14
+
+// Weapon describes a melee weapon. Distances are in pixels and times in seconds.
+type Weapon struct {
+	Name  string
+	Image *ebiten.Image
+	// Damage dealt to every enemy caught by a swing
+	Damage int
+	// Reach is how far the hitbox extends in front of the wielder; Width is its size across
+	Reach float64
+	Width float64
+	// SwingTime is how long a swing lasts; it hits during the first ActiveTime of it
+	SwingTime  float64
+	ActiveTime float64
+	// Cooldown is the time between the start of two swings
+	Cooldown float64
+	// Knockback pushes hit enemies away by this many pixels
+	Knockback float64
+}
+
+// Weapons holds every known weapon by name, see LoadWeapons.
+var Weapons = map[string]*Weapon{
+	"sword":     {Name: "sword", Damage: 1, Reach: 12, Width: 14, SwingTime: 0.25, ActiveTime: 0.15, Cooldown: 0.4, Knockback: 8},
+	"ironsword": {Name: "ironsword", Damage: 2, Reach: 13, Width: 16, SwingTime: 0.25, ActiveTime: 0.15, Cooldown: 0.45, Knockback: 10},
+	"goldsword": {Name: "goldsword", Damage: 3, Reach: 14, Width: 18, SwingTime: 0.3, ActiveTime: 0.18, Cooldown: 0.5, Knockback: 12},
+}
+
+// LoadWeapons loads the weapon sprites. Missing files are logged and leave the weapon without a sprite.
+func LoadWeapons() {
+	files := map[string]string{
+		"sword":     "assets/sword.png",
+		"ironsword": "assets/ironsword.png",
+		"goldsword": "assets/goldsword.png",
+	}
+
+	for name, path := range files {
+		img, _, err := ebitenutil.NewImageFromFile(path)
+		if err != nil {
+			log.Printf("warning: could not load %s: %v", path, err)
+			continue
+		}
+		if w, ok := Weapons[name]; ok {
+			w.Image = img
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// BUTTON_PADDING is the space around the label of a button drawn without images
+const BUTTON_PADDING = 4
+
+// Button is clicked with the mouse or Confirm. It draws its image for the current state,
+// scaled, or a box of the theme's colors; the label is drawn centered over either.
+type Button struct {
+	Base
+	Label string
+	// Images are the button's looks by state; a missing state uses STATE_NORMAL's,
+	// tinted for hover and disabled
+	Images [STATE_COUNT]*ebiten.Image
+	// Scale multiplies the size of the images, 1 when 0
+	Scale float64
+	// Hitbox is the clickable part of the button relative to its top-left corner, all of it when empty
+	Hitbox  image.Rectangle
+	OnClick func()
+}
+
+func NewButton(label string, onClick func()) *Button {
+	return &Button{Label: label, OnClick: onClick}
+}
+
+// NewImageButton returns a button drawn with a normal and a pressed image.
+func NewImageButton(normal, pressed *ebiten.Image, scale float64, onClick func()) *Button {
+	b := &Button{Scale: scale, OnClick: onClick}
+	b.Images[STATE_NORMAL] = normal
+	b.Images[STATE_PRESSED] = pressed
+	return b
+}
+
+func (b *Button) scale() float64 {
+	if b.Scale == 0 {
+		return 1
+	}
+	return b.Scale
+}
+
+func (b *Button) MinSize() image.Point {
+	if img := b.Images[STATE_NORMAL]; img != nil {
+		s := img.Bounds().Size()
+		return image.Pt(int(float64(s.X)*b.scale()), int(float64(s.Y)*b.scale()))
+	}
+	return textSize(b.Label).Add(image.Pt(2*BUTTON_PADDING, 2*BUTTON_PADDING))
+}
+
+func (b *Button) Hit(p image.Point) bool {
+	r := b.Bounds()
+	if !b.Hitbox.Empty() {
+		r = b.Hitbox.Add(r.Min).Intersect(r)
+	}
+	return p.In(r)
+}
+
+func (b *Button) Activate(u *UI) {
+	if b.OnClick != nil {
+		b.OnClick()
+	}
+}
+
+func (b *Button) Draw(dst *ebiten.Image, u *UI) {
+	state := u.StateOf(b)
+	r := b.Bounds()
+	if img := b.Images[STATE_NORMAL]; img != nil {
+		op := &ebiten.DrawImageOptions{}
+		if own := b.Images[state]; own != nil {
+			img = own
+		} else if state == STATE_HOVER {
+			op.ColorScale.Scale(1.2, 1.2, 1.2, 1)
+		} else if state == STATE_DISABLED {
+			op.ColorScale.Scale(0.5, 0.5, 0.5, 0.8)
+		}
+		s := img.Bounds().Size()
+		op.GeoM.Scale(float64(r.Dx())/float64(s.X), float64(r.Dy())/float64(s.Y))
+		op.GeoM.Translate(float64(r.Min.X), float64(r.Min.Y))
+		dst.DrawImage(img, op)
+	} else {
+		fillRect(dst, r, u.Theme.Fill[state])
+	}
+	if b.Label != "" {
+		t := textSize(b.Label)
+		ebitenutil.DebugPrintAt(dst, b.Label, r.Min.X+(r.Dx()-t.X)/2, r.Min.Y+(r.Dy()-t.Y)/2)
+	}
+}
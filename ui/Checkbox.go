@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// CHECKBOX_SIZE is the side of a checkbox's box
+const CHECKBOX_SIZE = 10
+
+// Checkbox is a box with a label, toggled by a click or Confirm.
+type Checkbox struct {
+	Base
+	Label    string
+	Checked  bool
+	OnChange func(checked bool)
+}
+
+func NewCheckbox(label string, checked bool, onChange func(bool)) *Checkbox {
+	return &Checkbox{Label: label, Checked: checked, OnChange: onChange}
+}
+
+func (c *Checkbox) MinSize() image.Point {
+	t := textSize(c.Label)
+	return image.Pt(CHECKBOX_SIZE+CHAR_WIDTH+t.X, max(CHECKBOX_SIZE, t.Y))
+}
+
+func (c *Checkbox) Activate(u *UI) {
+	c.Checked = !c.Checked
+	if c.OnChange != nil {
+		c.OnChange(c.Checked)
+	}
+}
+
+func (c *Checkbox) Draw(dst *ebiten.Image, u *UI) {
+	r := c.Bounds()
+	y := r.Min.Y + (r.Dy()-CHECKBOX_SIZE)/2
+	box := image.Rect(r.Min.X, y, r.Min.X+CHECKBOX_SIZE, y+CHECKBOX_SIZE)
+	fillRect(dst, box, u.Theme.Fill[u.StateOf(c)])
+	if c.Checked {
+		fillRect(dst, box.Inset(2), u.Theme.Accent)
+	}
+	ebitenutil.DebugPrintAt(dst, c.Label, box.Max.X+CHAR_WIDTH, r.Min.Y+(r.Dy()-LINE_HEIGHT)/2)
+}
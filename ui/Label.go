@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Label is a line of text. It doesn't take the focus.
+type Label struct {
+	Base
+	Text string
+	// Center centers the text in the label's bounds instead of starting at their left edge
+	Center bool
+}
+
+func NewLabel(text string) *Label {
+	return &Label{Text: text}
+}
+
+func (l *Label) MinSize() image.Point {
+	return textSize(l.Text)
+}
+
+func (l *Label) Draw(dst *ebiten.Image, u *UI) {
+	r := l.Bounds()
+	x := r.Min.X
+	if l.Center {
+		x += (r.Dx() - textSize(l.Text).X) / 2
+	}
+	ebitenutil.DebugPrintAt(dst, l.Text, x, r.Min.Y+(r.Dy()-LINE_HEIGHT)/2)
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type Axis int
+
+const (
+	HORIZONTAL Axis = iota
+	VERTICAL
+)
+
+// Stack lays its children out in a row or a column at their minimum size along the axis.
+// Across the axis they keep their minimum size at the start of the stack, or fill it with Stretch.
+type Stack struct {
+	Base
+	Axis    Axis
+	Items   []Widget
+	Spacing int
+	Padding int
+	Stretch bool
+}
+
+// NewVBox returns a column of widgets.
+func NewVBox(spacing int, items ...Widget) *Stack {
+	return &Stack{Axis: VERTICAL, Items: items, Spacing: spacing}
+}
+
+// NewHBox returns a row of widgets.
+func NewHBox(spacing int, items ...Widget) *Stack {
+	return &Stack{Axis: HORIZONTAL, Items: items, Spacing: spacing}
+}
+
+func (s *Stack) Children() []Widget {
+	return s.Items
+}
+
+func (s *Stack) MinSize() image.Point {
+	var size image.Point
+	for i, w := range s.Items {
+		m := w.MinSize()
+		gap := 0
+		if i > 0 {
+			gap = s.Spacing
+		}
+		if s.Axis == VERTICAL {
+			size.X = max(size.X, m.X)
+			size.Y += gap + m.Y
+		} else {
+			size.X += gap + m.X
+			size.Y = max(size.Y, m.Y)
+		}
+	}
+	return size.Add(image.Pt(2*s.Padding, 2*s.Padding))
+}
+
+func (s *Stack) SetBounds(r image.Rectangle) {
+	s.Base.SetBounds(r)
+	inner := r.Inset(s.Padding)
+	pos := inner.Min
+	for _, w := range s.Items {
+		m := w.MinSize()
+		if s.Axis == VERTICAL {
+			if s.Stretch {
+				m.X = inner.Dx()
+			}
+			w.SetBounds(image.Rectangle{Min: pos, Max: pos.Add(m)})
+			pos.Y += m.Y + s.Spacing
+		} else {
+			if s.Stretch {
+				m.Y = inner.Dy()
+			}
+			w.SetBounds(image.Rectangle{Min: pos, Max: pos.Add(m)})
+			pos.X += m.X + s.Spacing
+		}
+	}
+}
+
+func (s *Stack) Draw(dst *ebiten.Image, u *UI) {
+	for _, w := range s.Items {
+		w.Draw(dst, u)
+	}
+}
+
+// AnchorPoint is a point of a rectangle: its corners, the middles of its edges or its center.
+type AnchorPoint int
+
+const (
+	ANCHOR_TOP_LEFT AnchorPoint = iota
+	ANCHOR_TOP
+	ANCHOR_TOP_RIGHT
+	ANCHOR_LEFT
+	ANCHOR_CENTER
+	ANCHOR_RIGHT
+	ANCHOR_BOTTOM_LEFT
+	ANCHOR_BOTTOM
+	ANCHOR_BOTTOM_RIGHT
+)
+
+// fraction returns where the point is across and down a rectangle, 0, 1/2 or 1 each.
+func (a AnchorPoint) fraction() (float64, float64) {
+	return float64(a%3) / 2, float64(a/3) / 2
+}
+
+// Anchored is a widget pinned by its At point to the same point of an Anchor's bounds, moved by Offset.
+type Anchored struct {
+	Widget Widget
+	At     AnchorPoint
+	Offset image.Point
+}
+
+// Anchor places each of its widgets at its minimum size against a point of its bounds,
+// like a menu in the top-left corner and a version label at the bottom right.
+type Anchor struct {
+	Base
+	Items []Anchored
+}
+
+func NewAnchor(items ...Anchored) *Anchor {
+	return &Anchor{Items: items}
+}
+
+func (a *Anchor) Children() []Widget {
+	children := make([]Widget, len(a.Items))
+	for i, it := range a.Items {
+		children[i] = it.Widget
+	}
+	return children
+}
+
+func (a *Anchor) MinSize() image.Point {
+	var size image.Point
+	for _, it := range a.Items {
+		m := it.Widget.MinSize().Add(image.Pt(abs(it.Offset.X), abs(it.Offset.Y)))
+		size.X = max(size.X, m.X)
+		size.Y = max(size.Y, m.Y)
+	}
+	return size
+}
+
+func (a *Anchor) SetBounds(r image.Rectangle) {
+	a.Base.SetBounds(r)
+	for _, it := range a.Items {
+		m := it.Widget.MinSize()
+		fx, fy := it.At.fraction()
+		x := r.Min.X + int(fx*float64(r.Dx()-m.X)) + it.Offset.X
+		y := r.Min.Y + int(fy*float64(r.Dy()-m.Y)) + it.Offset.Y
+		it.Widget.SetBounds(image.Rect(x, y, x+m.X, y+m.Y))
+	}
+}
+
+func (a *Anchor) Draw(dst *ebiten.Image, u *UI) {
+	for _, it := range a.Items {
+		it.Widget.Draw(dst, u)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Spacer is empty space of a fixed size, e.g. to give a stretched stack a minimum width.
+type Spacer struct {
+	Base
+	Size image.Point
+}
+
+func NewSpacer(w, h int) *Spacer {
+	return &Spacer{Size: image.Pt(w, h)}
+}
+
+func (s *Spacer) MinSize() image.Point {
+	return s.Size
+}
+
+func (s *Spacer) Draw(dst *ebiten.Image, u *UI) {}
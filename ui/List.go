@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// List shows rows of text, one of them selected. Clicking a row selects it; when focused,
+// Up and Down move the selection and Confirm chooses it. Lists longer than Rows scroll.
+type List struct {
+	Base
+	Items    []string
+	Selected int
+	// Rows is how many rows are visible, all of them when 0
+	Rows int
+	// OnSelect is called when a row is clicked or the selected one is confirmed
+	OnSelect func(index int)
+
+	// scroll is the first visible row
+	scroll int
+}
+
+func NewList(items []string, rows int, onSelect func(int)) *List {
+	return &List{Items: items, Rows: rows, OnSelect: onSelect}
+}
+
+func (l *List) visibleRows() int {
+	if l.Rows <= 0 || l.Rows > len(l.Items) {
+		return len(l.Items)
+	}
+	return l.Rows
+}
+
+func (l *List) MinSize() image.Point {
+	w := 0
+	for _, it := range l.Items {
+		w = max(w, textSize(it).X)
+	}
+	return image.Pt(w+2*BUTTON_PADDING, l.visibleRows()*LINE_HEIGHT)
+}
+
+// Select makes row i the selected one and scrolls it into view.
+func (l *List) Select(i int) {
+	if i < 0 || i >= len(l.Items) {
+		return
+	}
+	l.Selected = i
+	rows := l.visibleRows()
+	if i < l.scroll {
+		l.scroll = i
+	} else if i >= l.scroll+rows {
+		l.scroll = i - rows + 1
+	}
+}
+
+// Activate selects the row under the cursor when clicked, then reports the selection.
+func (l *List) Activate(u *UI) {
+	cursor := image.Pt(u.In.CursorX, u.In.CursorY)
+	if u.Clicking() && cursor.In(l.Bounds()) {
+		l.Select(l.scroll + (cursor.Y-l.Bounds().Min.Y)/LINE_HEIGHT)
+	}
+	if l.OnSelect != nil && len(l.Items) > 0 {
+		l.OnSelect(l.Selected)
+	}
+}
+
+// Navigate moves the selection with Up and Down; past the first and the last row the focus moves on.
+func (l *List) Navigate(dx, dy int) bool {
+	next := l.Selected + dy
+	if dy == 0 || next < 0 || next >= len(l.Items) {
+		return false
+	}
+	l.Select(next)
+	return true
+}
+
+func (l *List) Draw(dst *ebiten.Image, u *UI) {
+	r := l.Bounds()
+	fillRect(dst, r, u.Theme.Fill[STATE_NORMAL])
+	end := min(len(l.Items), l.scroll+l.visibleRows())
+	for i := l.scroll; i < end; i++ {
+		y := r.Min.Y + (i-l.scroll)*LINE_HEIGHT
+		if i == l.Selected {
+			fillRect(dst, image.Rect(r.Min.X, y, r.Max.X, y+LINE_HEIGHT), u.Theme.Fill[STATE_HOVER])
+		}
+		ebitenutil.DebugPrintAt(dst, l.Items[i], r.Min.X+BUTTON_PADDING, y)
+	}
+}
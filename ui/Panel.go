@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Panel draws a background behind its child, which fills it inside the padding.
+type Panel struct {
+	Base
+	Child      Widget
+	Padding    int
+	Background color.Color
+}
+
+func NewPanel(child Widget, padding int, background color.Color) *Panel {
+	return &Panel{Child: child, Padding: padding, Background: background}
+}
+
+func (p *Panel) Children() []Widget {
+	return []Widget{p.Child}
+}
+
+func (p *Panel) MinSize() image.Point {
+	return p.Child.MinSize().Add(image.Pt(2*p.Padding, 2*p.Padding))
+}
+
+func (p *Panel) SetBounds(r image.Rectangle) {
+	p.Base.SetBounds(r)
+	p.Child.SetBounds(r.Inset(p.Padding))
+}
+
+func (p *Panel) Draw(dst *ebiten.Image, u *UI) {
+	if p.Background != nil {
+		fillRect(dst, p.Bounds(), p.Background)
+	}
+	p.Child.Draw(dst, u)
+}
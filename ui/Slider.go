@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// size of a slider's track when nothing stretches it, and of its knob
+const (
+	SLIDER_WIDTH      = 80
+	SLIDER_HEIGHT     = 12
+	SLIDER_KNOB_WIDTH = 4
+)
+
+// Slider picks a value between Min and Max by dragging it, or with Left and Right when focused.
+type Slider struct {
+	Base
+	Min, Max, Value float64
+	// Step is how much Left and Right change the value, and what it snaps to; a tenth of the range when 0
+	Step     float64
+	OnChange func(value float64)
+}
+
+func NewSlider(min, max, value float64, onChange func(float64)) *Slider {
+	return &Slider{Min: min, Max: max, Value: value, OnChange: onChange}
+}
+
+func (s *Slider) step() float64 {
+	if s.Step > 0 {
+		return s.Step
+	}
+	return (s.Max - s.Min) / 10
+}
+
+// SetValue clamps v to the range, snaps it to the step and calls OnChange if it changed.
+func (s *Slider) SetValue(v float64) {
+	step := s.step()
+	if step > 0 {
+		v = s.Min + math.Round((v-s.Min)/step)*step
+	}
+	v = max(s.Min, min(s.Max, v))
+	if v == s.Value {
+		return
+	}
+	s.Value = v
+	if s.OnChange != nil {
+		s.OnChange(v)
+	}
+}
+
+func (s *Slider) MinSize() image.Point {
+	return image.Pt(SLIDER_WIDTH, SLIDER_HEIGHT)
+}
+
+// Activate does nothing: sliders are changed by dragging and navigating.
+func (s *Slider) Activate(u *UI) {}
+
+func (s *Slider) Navigate(dx, dy int) bool {
+	if dx == 0 {
+		return false
+	}
+	s.SetValue(s.Value + float64(dx)*s.step())
+	return true
+}
+
+func (s *Slider) Drag(x, y int) {
+	r := s.Bounds()
+	if r.Dx() <= 0 {
+		return
+	}
+	f := float64(x-r.Min.X) / float64(r.Dx())
+	s.SetValue(s.Min + f*(s.Max-s.Min))
+}
+
+// fraction returns how far the value is along the range, from 0 to 1.
+func (s *Slider) fraction() float64 {
+	if s.Max <= s.Min {
+		return 0
+	}
+	return (s.Value - s.Min) / (s.Max - s.Min)
+}
+
+func (s *Slider) Draw(dst *ebiten.Image, u *UI) {
+	r := s.Bounds()
+	state := u.StateOf(s)
+	fillRect(dst, r, u.Theme.Fill[state])
+	x := r.Min.X + int(s.fraction()*float64(r.Dx()))
+	if state != STATE_DISABLED {
+		fillRect(dst, image.Rect(r.Min.X, r.Min.Y+r.Dy()/3, x, r.Max.Y-r.Dy()/3), u.Theme.Accent)
+	}
+	knob := image.Rect(x-SLIDER_KNOB_WIDTH/2, r.Min.Y, x+SLIDER_KNOB_WIDTH/2, r.Max.Y).Intersect(r)
+	fillRect(dst, knob, u.Theme.Fill[STATE_HOVER])
+}
@@ -0,0 +1,324 @@
+// Package ui is a small widget toolkit for the game's menus: buttons, labels, panels, sliders,
+// checkboxes and lists, laid out by containers and driven by the mouse or by navigation input
+// from the keyboard and gamepads.
+package ui
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// size of a character of ebitenutil's debug font, which the widgets draw their text with
+const (
+	CHAR_WIDTH  = 6
+	LINE_HEIGHT = 16
+)
+
+// Input is what the widgets see of one frame of input. Positions are in screen pixels; the
+// navigation fields are true on the frame their action was pressed.
+type Input struct {
+	CursorX, CursorY int
+	MouseDown        bool
+
+	Up, Down, Left, Right bool
+	Confirm               bool
+}
+
+// Widget is an element of the UI tree. Containers lay their children out in SetBounds.
+type Widget interface {
+	// MinSize is the smallest size the widget can be laid out at
+	MinSize() image.Point
+	SetBounds(r image.Rectangle)
+	Bounds() image.Rectangle
+	Draw(dst *ebiten.Image, u *UI)
+}
+
+// Container is a widget holding other widgets.
+type Container interface {
+	Widget
+	Children() []Widget
+}
+
+// Focusable widgets take the focus and receive clicks and Confirm.
+type Focusable interface {
+	Widget
+	// Enabled reports whether the widget reacts to input at the moment
+	Enabled() bool
+	// Activate is called on a click or Confirm
+	Activate(u *UI)
+}
+
+// Navigator is a focusable widget that uses navigation itself, like a slider moved with Left
+// and Right. It returns false for the directions the focus should move in instead.
+type Navigator interface {
+	Navigate(dx, dy int) bool
+}
+
+// HitTester is a widget clickable on only part of its bounds, like a round button.
+type HitTester interface {
+	Hit(p image.Point) bool
+}
+
+// hits reports whether p is over w.
+func hits(w Widget, p image.Point) bool {
+	if h, ok := w.(HitTester); ok {
+		return h.Hit(p)
+	}
+	return p.In(w.Bounds())
+}
+
+// Dragger is a focusable widget following the cursor while the mouse button is held on it.
+type Dragger interface {
+	Drag(x, y int)
+}
+
+// Base holds the bounds and the enabled state every widget has.
+type Base struct {
+	bounds   image.Rectangle
+	Disabled bool
+}
+
+func (b *Base) Bounds() image.Rectangle {
+	return b.bounds
+}
+
+func (b *Base) SetBounds(r image.Rectangle) {
+	b.bounds = r
+}
+
+func (b *Base) Enabled() bool {
+	return !b.Disabled
+}
+
+// State is how a widget shows the mouse and the focus.
+type State int
+
+const (
+	STATE_NORMAL State = iota
+	STATE_HOVER
+	STATE_PRESSED
+	STATE_DISABLED
+	STATE_COUNT
+)
+
+// Theme holds the colors of the widgets drawn without images.
+type Theme struct {
+	// Fill is the background of buttons and other boxes, by state
+	Fill [STATE_COUNT]color.Color
+	// Accent is the check of checkboxes, the filled part of sliders and the selected list row
+	Accent color.Color
+	// Focus outlines the focused widget
+	Focus color.Color
+}
+
+var DefaultTheme = &Theme{
+	Fill: [STATE_COUNT]color.Color{
+		STATE_NORMAL:   color.RGBA{0x20, 0x30, 0x58, 0xe0},
+		STATE_HOVER:    color.RGBA{0x30, 0x50, 0x90, 0xe0},
+		STATE_PRESSED:  color.RGBA{0x18, 0x24, 0x40, 0xf0},
+		STATE_DISABLED: color.RGBA{0x30, 0x30, 0x30, 0xa0},
+	},
+	Accent: color.RGBA{0x60, 0xa0, 0xf0, 0xff},
+	Focus:  color.RGBA{0xff, 0xe0, 0x40, 0xff},
+}
+
+// UI runs a tree of widgets: it tracks the widget under the cursor, the one the mouse button
+// went down on and the focused one, and turns the input into clicks and focus moves.
+type UI struct {
+	Root  Widget
+	Theme *Theme
+	// FocusVisible is set once the UI was navigated without the mouse; the focus is outlined then
+	FocusVisible bool
+	// In is the input of the latest Update
+	In Input
+
+	focus   Focusable
+	hover   Focusable
+	pressed Focusable
+	// clicking is set while the widget clicked is activated
+	clicking bool
+	// the previous frame's input, to see the cursor move and the button go down and up
+	prev     Input
+	prevSeen bool
+}
+
+func New(root Widget) *UI {
+	return &UI{Root: root, Theme: DefaultTheme}
+}
+
+// Layout places the tree in r.
+func (u *UI) Layout(r image.Rectangle) {
+	u.Root.SetBounds(r)
+}
+
+// Focused returns the focused widget, nil when no widget can take the focus.
+func (u *UI) Focused() Focusable {
+	return u.focus
+}
+
+// Hovered returns the enabled widget under the cursor, nil when there is none.
+func (u *UI) Hovered() Focusable {
+	return u.hover
+}
+
+// Clicking reports whether the widget being activated was clicked rather than confirmed.
+func (u *UI) Clicking() bool {
+	return u.clicking
+}
+
+// Focus moves the focus to w.
+func (u *UI) Focus(w Focusable) {
+	u.focus = w
+}
+
+// StateOf returns how w should be drawn.
+func (u *UI) StateOf(w Focusable) State {
+	switch {
+	case !w.Enabled():
+		return STATE_DISABLED
+	case u.pressed == w && u.hover == w:
+		return STATE_PRESSED
+	case u.hover == w:
+		return STATE_HOVER
+	}
+	return STATE_NORMAL
+}
+
+// focusables returns the enabled focusable widgets of the tree in drawing order.
+func (u *UI) focusables() []Focusable {
+	var list []Focusable
+	var walk func(w Widget)
+	walk = func(w Widget) {
+		if f, ok := w.(Focusable); ok && f.Enabled() {
+			list = append(list, f)
+		}
+		if c, ok := w.(Container); ok {
+			for _, child := range c.Children() {
+				walk(child)
+			}
+		}
+	}
+	if u.Root != nil {
+		walk(u.Root)
+	}
+	return list
+}
+
+func (u *UI) Update(in Input) {
+	u.In = in
+	list := u.focusables()
+	if !containsFocusable(list, u.focus) {
+		u.focus = nil
+		if len(list) > 0 {
+			u.focus = list[0]
+		}
+	}
+
+	u.hover = nil
+	cursor := image.Pt(in.CursorX, in.CursorY)
+	for _, f := range list {
+		if hits(f, cursor) {
+			u.hover = f
+		}
+	}
+	moved := u.prevSeen && (in.CursorX != u.prev.CursorX || in.CursorY != u.prev.CursorY)
+	if moved {
+		u.FocusVisible = false
+		if u.hover != nil {
+			u.focus = u.hover
+		}
+	}
+
+	// a click is the button going down and up over the same widget
+	wasDown := u.prevSeen && u.prev.MouseDown
+	switch {
+	case in.MouseDown && !wasDown:
+		u.pressed = u.hover
+		if u.pressed != nil {
+			u.focus = u.pressed
+		}
+	case !in.MouseDown && wasDown:
+		if u.pressed != nil && u.pressed == u.hover {
+			u.clicking = true
+			u.pressed.Activate(u)
+			u.clicking = false
+		}
+		u.pressed = nil
+	}
+	if d, ok := u.pressed.(Dragger); ok && in.MouseDown {
+		d.Drag(in.CursorX, in.CursorY)
+	}
+	u.prev, u.prevSeen = in, true
+
+	u.navigate(list)
+}
+
+func (u *UI) navigate(list []Focusable) {
+	in := u.In
+	dx, dy := 0, 0
+	switch {
+	case in.Up:
+		dy = -1
+	case in.Down:
+		dy = 1
+	case in.Left:
+		dx = -1
+	case in.Right:
+		dx = 1
+	case in.Confirm:
+		if u.focus != nil {
+			u.FocusVisible = true
+			u.focus.Activate(u)
+		}
+		return
+	default:
+		return
+	}
+	if u.focus == nil {
+		return
+	}
+	u.FocusVisible = true
+	if n, ok := u.focus.(Navigator); ok && n.Navigate(dx, dy) {
+		return
+	}
+	// Up and Left move the focus back through the tree, Down and Right forward
+	step := dx + dy
+	for i, f := range list {
+		if f == u.focus {
+			u.focus = list[(i+step+len(list))%len(list)]
+			return
+		}
+	}
+}
+
+func containsFocusable(list []Focusable, w Focusable) bool {
+	for _, f := range list {
+		if f == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UI) Draw(dst *ebiten.Image) {
+	if u.Root == nil {
+		return
+	}
+	u.Root.Draw(dst, u)
+	if u.FocusVisible && u.focus != nil {
+		r := u.focus.Bounds()
+		vector.StrokeRect(dst, float32(r.Min.X)-1, float32(r.Min.Y)-1, float32(r.Dx())+2, float32(r.Dy())+2, 1, u.Theme.Focus, false)
+	}
+}
+
+// textSize returns the size of a line of text in the debug font.
+func textSize(s string) image.Point {
+	return image.Pt(len(s)*CHAR_WIDTH, LINE_HEIGHT)
+}
+
+func fillRect(dst *ebiten.Image, r image.Rectangle, c color.Color) {
+	vector.FillRect(dst, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), c, false)
+}
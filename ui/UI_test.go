@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// click presses and releases the mouse at x, y.
+func click(u *UI, x, y int) {
+	u.Update(Input{CursorX: x, CursorY: y, MouseDown: true})
+	u.Update(Input{CursorX: x, CursorY: y})
+}
+
+func center(w Widget) (int, int) {
+	r := w.Bounds()
+	return (r.Min.X + r.Max.X) / 2, (r.Min.Y + r.Max.Y) / 2
+}
+
+func TestStackAndAnchorLayout(t *testing.T) {
+	a := NewButton("Play", nil)
+	b := NewButton("Quit game", nil)
+	box := NewVBox(4, a, b)
+	box.Stretch = true
+	u := New(NewAnchor(Anchored{Widget: box, At: ANCHOR_BOTTOM_RIGHT, Offset: image.Pt(-10, -10)}))
+	u.Layout(image.Rect(0, 0, 320, 128))
+
+	min := box.MinSize()
+	wantBox := image.Rect(310-min.X, 118-min.Y, 310, 118)
+	if box.Bounds() != wantBox {
+		t.Errorf("box at %v, want %v", box.Bounds(), wantBox)
+	}
+	if a.Bounds().Dx() != b.Bounds().Dx() {
+		t.Errorf("stretched buttons have widths %d and %d", a.Bounds().Dx(), b.Bounds().Dx())
+	}
+	if a.Bounds().Max.Y+4 != b.Bounds().Min.Y {
+		t.Errorf("buttons at %v and %v, want 4px apart", a.Bounds(), b.Bounds())
+	}
+}
+
+func TestButtonClick(t *testing.T) {
+	clicks := 0
+	b := NewButton("Play", func() { clicks++ })
+	off := NewButton("Off", func() { t.Error("a disabled button was clicked") })
+	off.Disabled = true
+	u := New(NewVBox(0, b, off))
+	u.Layout(image.Rect(0, 0, 100, 100))
+
+	x, y := center(b)
+	u.Update(Input{CursorX: x, CursorY: y, MouseDown: true})
+	if u.StateOf(b) != STATE_PRESSED {
+		t.Errorf("state %d while held, want pressed", u.StateOf(b))
+	}
+	u.Update(Input{CursorX: x, CursorY: y})
+	if clicks != 1 {
+		t.Fatalf("%d clicks, want 1", clicks)
+	}
+
+	// releasing away from the button cancels the click
+	u.Update(Input{CursorX: x, CursorY: y, MouseDown: true})
+	u.Update(Input{CursorX: 99, CursorY: 99})
+	if clicks != 1 {
+		t.Error("releasing outside the button clicked it")
+	}
+
+	ox, oy := center(off)
+	click(u, ox, oy)
+	if u.StateOf(off) != STATE_DISABLED {
+		t.Error("the disabled button should look disabled")
+	}
+
+	// only the hitbox of a button with one is clickable
+	b.Hitbox = image.Rect(4, 4, 8, 8)
+	r := b.Bounds()
+	click(u, r.Min.X+1, r.Min.Y+1)
+	if clicks != 1 {
+		t.Error("a click outside the hitbox clicked the button")
+	}
+	click(u, r.Min.X+5, r.Min.Y+5)
+	if clicks != 2 {
+		t.Errorf("%d clicks, want 2 after clicking the hitbox", clicks)
+	}
+}
+
+func TestFocusNavigation(t *testing.T) {
+	var got []string
+	a := NewButton("A", func() { got = append(got, "a") })
+	skip := NewButton("skip", nil)
+	skip.Disabled = true
+	c := NewButton("C", func() { got = append(got, "c") })
+	u := New(NewVBox(0, NewLabel("title"), a, skip, c))
+	u.Layout(image.Rect(0, 0, 100, 100))
+
+	u.Update(Input{})
+	if u.Focused() != Focusable(a) {
+		t.Fatal("the first focusable widget should have the focus")
+	}
+	u.Update(Input{Down: true})
+	u.Update(Input{Confirm: true})
+	u.Update(Input{Down: true})
+	u.Update(Input{Confirm: true})
+	if len(got) != 2 || got[0] != "c" || got[1] != "a" || !u.FocusVisible {
+		t.Errorf("activated %v, want [c a] skipping the label and the disabled button", got)
+	}
+
+	// moving the mouse over a widget focuses it and hides the outline
+	u.Update(Input{CursorX: 1, CursorY: 1})
+	cx, cy := center(c)
+	u.Update(Input{CursorX: cx, CursorY: cy})
+	if u.Focused() != Focusable(c) || u.FocusVisible {
+		t.Error("hovering should move the focus")
+	}
+}
+
+func TestSliderAndCheckbox(t *testing.T) {
+	var changed float64
+	s := NewSlider(0, 1, 0.5, func(v float64) { changed = v })
+	s.Step = 0.25
+	cb := NewCheckbox("Fullscreen", false, nil)
+	u := New(NewVBox(0, s, cb))
+	u.Layout(image.Rect(0, 0, 200, 100))
+
+	u.Update(Input{})
+	u.Update(Input{Right: true})
+	if s.Value != 0.75 || changed != 0.75 {
+		t.Errorf("Right moved the slider to %v, want 0.75", s.Value)
+	}
+	if u.Focused() != Focusable(s) {
+		t.Error("Left and Right should stay on the slider")
+	}
+
+	r := s.Bounds()
+	u.Update(Input{CursorX: r.Min.X + 1, CursorY: r.Min.Y + 1, MouseDown: true})
+	u.Update(Input{CursorX: r.Max.X + 50, CursorY: r.Min.Y + 1, MouseDown: true})
+	if s.Value != 1 {
+		t.Errorf("dragging past the end left the slider at %v", s.Value)
+	}
+	u.Update(Input{CursorX: r.Max.X + 50, CursorY: r.Min.Y + 1})
+
+	cx, cy := center(cb)
+	click(u, cx, cy)
+	if !cb.Checked {
+		t.Error("clicking the checkbox should check it")
+	}
+	u.Update(Input{CursorX: cx, CursorY: cy, Confirm: true})
+	if cb.Checked {
+		t.Error("Confirm on the focused checkbox should uncheck it")
+	}
+}
+
+func TestListNavigation(t *testing.T) {
+	chosen := -1
+	l := NewList([]string{"a", "b", "c", "d"}, 2, func(i int) { chosen = i })
+	after := NewButton("after", nil)
+	u := New(NewVBox(0, l, after))
+	u.Layout(image.Rect(0, 0, 100, 100))
+
+	u.Update(Input{})
+	for i := 0; i < 3; i++ {
+		u.Update(Input{Down: true})
+	}
+	if l.Selected != 3 || l.scroll != 2 || u.Focused() != Focusable(l) {
+		t.Fatalf("selected %d scrolled to %d, want the last row in view", l.Selected, l.scroll)
+	}
+	u.Update(Input{Down: true})
+	if u.Focused() != Focusable(after) {
+		t.Error("Down past the last row should move the focus on")
+	}
+
+	// the first visible row is c
+	click(u, l.Bounds().Min.X+2, l.Bounds().Min.Y+2)
+	if l.Selected != 2 || chosen != 2 {
+		t.Errorf("clicking the first visible row selected %d and chose %d, want 2", l.Selected, chosen)
+	}
+
+	// Confirm chooses the selected row even with the cursor resting on another one
+	x, y := l.Bounds().Min.X+2, l.Bounds().Min.Y+2
+	u.Update(Input{CursorX: x, CursorY: y, Down: true})
+	u.Update(Input{CursorX: x, CursorY: y, Confirm: true})
+	if l.Selected != 3 || chosen != 3 {
+		t.Errorf("Confirm with the cursor on row 2 selected %d and chose %d, want 3", l.Selected, chosen)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	img := ebiten.NewImage(16, 16)
+	b := NewImageButton(img, nil, 2, nil)
+	if b.MinSize() != image.Pt(32, 32) {
+		t.Errorf("image button min size %v, want 32x32", b.MinSize())
+	}
+	u := New(NewPanel(NewVBox(2, b, NewLabel("label"), NewSlider(0, 1, 0, nil),
+		NewCheckbox("check", true, nil), NewList([]string{"row"}, 0, nil)), 4, DefaultTheme.Fill[STATE_NORMAL]))
+	u.Layout(image.Rect(0, 0, 320, 200))
+	u.FocusVisible = true
+	u.Update(Input{})
+	u.Draw(ebiten.NewImage(320, 200))
+}